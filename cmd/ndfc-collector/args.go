@@ -20,35 +20,75 @@ package main
 
 import (
 	"ndfc-collector/pkg/config"
-
-	"github.com/alecthomas/kong"
 )
 
 var version = "(dev)"
 
+// CLI is the top-level command line. Collection is the default command, so
+// its flags may be given without naming it.
+type CLI struct {
+	Collect Args       `kong:"cmd,default='withargs',help='Collect data from NDFC (default)'"`
+	Decrypt DecryptCmd `kong:"cmd,help='Decrypt an encrypted collection archive'"`
+	Verify  VerifyCmd  `kong:"cmd,help='Check a collection archive for tampering and missing data'"`
+	Inspect InspectCmd `kong:"cmd,help='Summarize the contents of a collection archive'"`
+	Export  ExportCmd  `kong:"cmd,help='Export a collection archive for ingestion by other tools'"`
+	Check   CheckCmd   `kong:"cmd,help='Run health checks against a collection archive'"`
+	Report  ReportCmd  `kong:"cmd,help='Render an HTML summary of a collection archive'"`
+	Serve   ServeCmd   `kong:"cmd,help='Run scheduled collections and serve the status and job API'"`
+}
+
 // Args are command line parameters.
 type Args struct {
 	URL               string            `kong:"--url,env='NDFC_URL',help='NDFC hostname or IP address'"`
 	Username          string            `kong:"--username,env='NDFC_USERNAME',help='NDFC username'"`
 	Password          string            `kong:"--password,env='NDFC_PASSWORD',help='NDFC password'"`
+	ControllerVersion string            `kong:"--controller-version,help='NDFC release to select requests for, e.g. 12.2.2 (default: detected)'"`
 	Output            string            `kong:"-o,default='ndfc-collection-data.zip',help='Output file'"`
+	Format            string            `kong:"--format,help='Archive format (zip, tar.gz, tar.zst, dir); inferred from the output file extension by default'"`
+	VolumeSize        string            `kong:"--volume-size,help='Split the zip archive into volumes of at most this size, e.g. 100MB'"`
+	Normalize         bool              `kong:"--normalize,help='Pretty-print responses with sorted keys and list items for diffable archives'"`
+	Baseline          string            `kong:"--baseline,help='Collect incrementally, carrying forward unchanged responses from this previous archive'"`
+	Cache             bool              `kong:"--cache,help='Keep responses in a response cache and only download them again when changed'"`
+	CacheDir          string            `kong:"--cache-dir,help='Directory of the response cache (default: the user cache directory)'"`
+	CacheMaxAge       int               `kong:"--cache-max-age,default='7',help='Days after which unused response cache entries are removed (0 to keep them)'"`
+	DBOutput          string            `kong:"--db-output,help='Also write list items to this buntDB file, keyed by db_key:id'"`
+	SQLiteOutput      string            `kong:"--sqlite-output,help='Also write list items to this SQLite database, one table per db_key'"`
+	Checks            string            `kong:"--checks,help='YAML health checks file (default: built-in checks)'"`
+	CheckOutput       string            `kong:"--check-output,help='Run health checks after collecting and write the report here (.json, .xml for JUnit, or text)'"`
+	ReportOutput      string            `kong:"--report-output,help='Also write an HTML summary of the collection to this file'"`
+	MetricsListen     string            `kong:"--metrics-listen,help='Serve Prometheus metrics on this address during the run, e.g. :9100'"`
+	TraceOutput       string            `kong:"--trace-output,help='Write an OpenTelemetry trace of the run to this JSON file'"`
+	TraceEndpoint     string            `kong:"--trace-endpoint,env='OTEL_EXPORTER_OTLP_ENDPOINT',help='Export an OpenTelemetry trace of the run to this OTLP/HTTP collector, e.g. http://localhost:4318'"`
 	ConfigFile        string            `kong:"-c,--config,help='Path to YAML configuration file'"`
 	RequestRetryCount int               `kong:"--request-retry-count,default='3',help='Times to retry a failed request'"`
 	RetryDelay        int               `kong:"--retry-delay,default='10',help='Seconds to wait before retry'"`
 	BatchSize         int               `kong:"--batch-size,default='7',help='Max request to send in parallel'"`
 	PageSize          int               `kong:"--page-size,default='1000',help='Object per page for large datasets'"`
+	RateLimit         float64           `kong:"--rate-limit,default='0',help='Max requests per second (0 for unlimited)'"`
+	RateBurst         int               `kong:"--rate-burst,default='7',help='Requests allowed in a burst above the rate limit'"`
+	AdaptiveRate      bool              `kong:"--adaptive-rate,help='Slow down automatically when NDFC is struggling'"`
+	Compression       string            `kong:"--compression,default='deflate',enum='deflate,zstd,store',help='Archive compression method (deflate, zstd, store)'"`
+	CompressionLevel  string            `kong:"--compression-level,default='default',enum='store,fast,default,best',help='Archive compression level (store, fast, default, best)'"`
+	EncryptTo         []string          `kong:"--encrypt-to,help='Encrypt the archive to this age public key (repeatable)'"`
+	EncryptPassphrase string            `kong:"--encrypt-passphrase,env='NDFC_ENCRYPT_PASSPHRASE',help='Encrypt the archive with this passphrase (age scrypt)'"`
+	SignKey           string            `kong:"--sign-key,help='Sign the archive manifest with this PEM ed25519 private key'"`
+	Redact            bool              `kong:"--redact,help='Redact serials, IPs, hostnames, usernames and passwords'"`
+	RedactRules       string            `kong:"--redact-rules,help='Path to YAML redaction rules (default: built-in rules)'"`
+	RedactKey         string            `kong:"--redact-key,env='NDFC_REDACT_KEY',help='Secret key for consistent pseudonyms across collections'"`
+	RedactMappingFile string            `kong:"--redact-mapping-file,help='Write the encrypted token to value mapping to this file'"`
 	Confirm           bool              `kong:"-y,help='Skip confirmation'"`
 	Verbose           bool              `kong:"-v,--verbose,help='Enable verbose (debug level) logging'"`
+	Quiet             bool              `kong:"--quiet,help='Log each request instead of showing the progress display'"`
+	LogFormat         string            `kong:"--log-format,default='console',enum='console,json',help='Log output format (console, json)'"`
+	LogLevel          string            `kong:"--log-level,help='Log level (trace, debug, info, warn, error); overrides --verbose'"`
 	Endpoint          string            `kong:"--endpoint,default='all',help='Collect a single endpoint'"`
+	DryRun            bool              `kong:"--dry-run,help='Print the requests a collection would send without collecting'"`
 	Query             map[string]string `kong:"-q,help='Query(s) to filter single endpoint query'"`
 	Version           bool              `kong:"--version,help='Show version'"`
 }
 
 // readArgs collects the CLI args and returns a config.Config.
-func readArgs() (*config.Config, error) {
-	var args Args
-	_ = kong.Parse(&args)
-
+func readArgs(args *Args) (*config.Config, error) {
 	if args.Version {
 		println("NDFC Collector", version)
 		return nil, nil
@@ -66,14 +106,45 @@ func readArgs() (*config.Config, error) {
 		cfg = &c
 		cfg.URL = args.URL
 		cfg.Output = args.Output
+		cfg.Format = args.Format
+		cfg.VolumeSize = args.VolumeSize
+		cfg.Normalize = args.Normalize
+		cfg.Baseline = args.Baseline
+		cfg.Cache = args.Cache
+		cfg.CacheDir = args.CacheDir
+		cfg.CacheMaxAge = args.CacheMaxAge
+		cfg.DBOutput = args.DBOutput
+		cfg.SQLiteOutput = args.SQLiteOutput
+		cfg.Checks = args.Checks
+		cfg.CheckOutput = args.CheckOutput
+		cfg.ReportOutput = args.ReportOutput
+		cfg.MetricsListen = args.MetricsListen
+		cfg.TraceOutput = args.TraceOutput
+		cfg.TraceEndpoint = args.TraceEndpoint
 		cfg.Username = args.Username
 		cfg.Password = args.Password
+		cfg.ControllerVersion = args.ControllerVersion
 		cfg.RequestRetryCount = args.RequestRetryCount
 		cfg.RetryDelay = args.RetryDelay
 		cfg.BatchSize = args.BatchSize
 		cfg.PageSize = args.PageSize
+		cfg.RateLimit = args.RateLimit
+		cfg.RateBurst = args.RateBurst
+		cfg.AdaptiveRate = args.AdaptiveRate
+		cfg.Compression = args.Compression
+		cfg.CompressionLevel = args.CompressionLevel
+		cfg.EncryptRecipients = args.EncryptTo
+		cfg.EncryptPassphrase = args.EncryptPassphrase
+		cfg.SignKey = args.SignKey
+		cfg.Redact = args.Redact
+		cfg.RedactRules = args.RedactRules
+		cfg.RedactKey = args.RedactKey
+		cfg.RedactMappingFile = args.RedactMappingFile
 		cfg.Confirm = args.Confirm
 		cfg.Verbose = args.Verbose
+		cfg.Quiet = args.Quiet
+		cfg.LogFormat = args.LogFormat
+		cfg.LogLevel = args.LogLevel
 		cfg.Endpoint = args.Endpoint
 		cfg.Query = args.Query
 	}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/check"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/brightpuddle/gobits/errors"
+)
+
+// CheckCmd evaluates health checks against a collection archive.
+type CheckCmd struct {
+	Archive string `kong:"arg,help='Collection archive (zip, tar.gz, tar.zst, directory, or any volume of a split archive)'"`
+	Checks  string `kong:"help='YAML checks file (default: built-in checks)'"`
+	Format  string `kong:"default='text',enum='text,json,junit',help='Report format (text, json, junit)'"`
+	Output  string `kong:"short='o',default='-',help='Output file, or - for standard output'"`
+	FailOn  string `kong:"default='critical',enum='none,info,warning,critical',help='Fail when there are findings of at least this severity (none, info, warning, critical)'"`
+}
+
+// Run writes the findings report and fails according to FailOn.
+func (cmd *CheckCmd) Run() error {
+	report, err := runChecks(cmd.Archive, nil, cmd.Checks)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if cmd.Output != "-" {
+		f, err := os.Create(cmd.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := report.Write(w, cmd.Format); err != nil {
+		return err
+	}
+
+	if cmd.FailOn == "none" {
+		return nil
+	}
+	if n := report.Count(check.Severity(cmd.FailOn)); n > 0 {
+		return fmt.Errorf("%d findings of severity %s or higher", n, cmd.FailOn)
+	}
+	return nil
+}
+
+// runChecks evaluates the checks in checksFile, or the built-in checks,
+// against the archive at path. catalog defaults to the built-in requests.
+func runChecks(path string, catalog []requests.Request, checksFile string) (*check.Report, error) {
+	checks, err := check.DefaultChecks()
+	if checksFile != "" {
+		checks, err = check.LoadChecks(checksFile)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if catalog == nil {
+		if catalog, err = requests.GetRequests(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	r, err := archive.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer r.Close()
+	report, err := check.Run(r, catalog, checks, time.Now())
+	if err != nil {
+		return nil, errors.WithStack(fmt.Errorf("cannot check %s: %w", path, err))
+	}
+	for _, warning := range report.Warnings() {
+		log.Warn().Msg(warning)
+	}
+	return report, nil
+}
+
+// writeCheckReport evaluates the checks against a finished collection
+// archive and writes the report for the check_output setting, in the
+// format implied by its extension.
+func writeCheckReport(path string, catalog []requests.Request, checksFile, output string) error {
+	report, err := runChecks(path, catalog, checksFile)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = report.Write(f, check.FormatFromName(output))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if n := report.Count(check.Warning); n > 0 {
+		log.Warn().Msgf("Health checks raised %d warnings or worse; see %s.", n, output)
+	} else {
+		log.Info().Msgf("Health checks passed; see %s.", output)
+	}
+	return nil
+}
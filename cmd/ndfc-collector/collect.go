@@ -17,14 +17,17 @@
 package main
 
 import (
+	"errors"
 	"regexp"
+	"sort"
 	"sync"
+	"time"
 
-	"github.com/brightpuddle/gobits/log"
 	"github.com/tidwall/gjson"
 	"golang.org/x/sync/errgroup"
 
 	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/baseline"
 	"ndfc-collector/pkg/cli"
 	"ndfc-collector/pkg/config"
 	"ndfc-collector/pkg/ndfc"
@@ -235,50 +238,74 @@ func expandLevel(
 	return expanded
 }
 
-// extractListResult extracts the list items from a parent API response using
-// the list_path declared in requests.yaml. When an endpoint wraps its array in
-// an object (e.g. {"fabrics": [...]}, list_path "fabrics"), this returns the
-// inner array so that expandLevel can iterate over the individual items and
-// fill {placeholder} substitutions for child requests.
-// list_path "" (single object) and "@this" (already a root array) are returned
-// unchanged.
-func extractListResult(result gjson.Result, listPath string) gjson.Result {
-	if listPath == "" || listPath == "@this" {
-		return result
+// dependencyKeys returns, for each parent URL template, the sorted JSON keys
+// that its dependent requests read from the parent's response items. Only
+// these fields are retained from parent responses for expansion.
+func dependencyKeys(reqs []requests.Request) map[string][]string {
+	seen := map[string]map[string]bool{}
+	for _, r := range reqs {
+		for _, dep := range r.DependsOn {
+			if seen[dep.URL] == nil {
+				seen[dep.URL] = map[string]bool{}
+			}
+			seen[dep.URL][dep.Key] = true
+		}
 	}
-	if extracted := result.Get(listPath); extracted.IsArray() {
-		return extracted
+	keys := make(map[string][]string, len(seen))
+	for url, set := range seen {
+		for key := range set {
+			keys[url] = append(keys[url], key)
+		}
+		sort.Strings(keys[url])
 	}
-	return result
+	return keys
 }
 
 // collectFabric executes all requests in topological dependency order.
 // Within each dependency level the expanded requests are batched and run
 // in parallel (up to cfg.BatchSize concurrent requests), preserving the
 // original homegrown batching behaviour.
+//
+// With a baseline, every request the baseline has cache validators for is
+// made conditional, and carried forward from the baseline only when NDFC
+// reports it unchanged.
 func collectFabric(
+	run *cli.Run,
 	client ndfc.Client,
 	arc archive.Writer,
 	reqs []requests.Request,
+	base *baseline.Baseline,
 	cfg *config.Config,
 ) error {
-	logger := log.New()
+	logger := run.Log
 
 	levels := buildLevels(reqs)
+	depKeys := dependencyKeys(reqs)
 
-	// allParentResults accumulates responses keyed by URL template for use
-	// when expanding child requests in later levels.
+	// allParentResults accumulates the projected list items of parent
+	// responses keyed by URL template for use when expanding child requests
+	// in later levels. Leaf responses are streamed to the archive only.
 	allParentResults := map[string][]parentResult{}
 
 	var firstErr error
 
+	// results records every request for the manifest.
+	var resultsMu sync.Mutex
+	var results []requests.Result
+
 	for levelIdx, levelReqs := range levels {
 		expanded := expandLevel(levelReqs, allParentResults)
 		if len(expanded) == 0 {
 			continue
 		}
 
-		logger.Info().Msgf("Fetching request level %d (%d requests)", levelIdx, len(expanded))
+		logger.Info().
+			Int("level", levelIdx).
+			Int("requests", len(expanded)).
+			Msgf("Fetching request level %d (%d requests)", levelIdx, len(expanded))
+		levelStart := time.Now()
+		run.Progress.StartLevel(levelIdx, len(expanded))
+		run.Trace.StartLevel(levelIdx, len(expanded))
 
 		type levelResult struct {
 			r   resolvedReq
@@ -303,11 +330,47 @@ func collectFabric(
 					fetchReq.URL = er.url
 					fetchReq.DBKey = er.resolvedKey
 					fetchReq.Query = er.query
+					fetchReq.Template = er.template.DBKey
+					fetchReq.Fabric = er.ctx["fabricName"]
+
+					templateURL := er.template.URL
+					if er.template.CatalogURL != "" {
+						templateURL = er.template.CatalogURL // export looks requests up by catalog URL
+					}
+					result := requests.Result{
+						Entry:    cli.EntryName(fetchReq),
+						URL:      er.url,
+						Template: templateURL,
+						DBKey:    er.resolvedKey,
+						Ctx:      er.ctx,
+					}
+					keys := depKeys[er.template.URL]
+					prev, _ := base.Lookup(result.Entry)
+
+					fetchReq.IfChanged = prev.Validators
+					resp, err := cli.FetchResult(run, client, fetchReq, keys, arc, cfg)
+					res := resp.Items
+					result.Validators = resp.Validators
+					if errors.Is(err, cli.ErrNotModified) {
+						if result.Validators.IsZero() {
+							result.Validators = prev.Validators
+						}
+						res, err = carry(run, base, fetchReq, keys, arc)
+						result.Carried = err == nil
+					}
+					if err != nil {
+						result.Error = err.Error()
+					}
+					resultsMu.Lock()
+					results = append(results, result)
+					resultsMu.Unlock()
 
-					res, err := cli.FetchResult(client, fetchReq, arc, cfg)
 					if err != nil {
 						return err
 					}
+					if len(keys) == 0 {
+						return nil
+					}
 
 					resultMu.Lock()
 					levelResults = append(levelResults, levelResult{er, res})
@@ -316,21 +379,48 @@ func collectFabric(
 				})
 			}
 
+			// Each failed request has already been logged by FetchResult.
 			if err := g.Wait(); err != nil {
-				logger.Error().Err(err).Msg("Error fetching data.")
 				if firstErr == nil {
 					firstErr = err
 				}
 			}
 		}
 
+		run.Metrics.Level(levelIdx, len(expanded), time.Since(levelStart))
+		logger.Debug().
+			Int("level", levelIdx).
+			Int("requests", len(expanded)).
+			Dur("duration", time.Since(levelStart)).
+			Msgf("Request level %d complete", levelIdx)
+
 		for _, lr := range levelResults {
 			allParentResults[lr.r.template.URL] = append(
 				allParentResults[lr.r.template.URL],
-				parentResult{ctx: lr.r.ctx, result: extractListResult(lr.res, lr.r.template.ListPath)},
+				parentResult{ctx: lr.r.ctx, result: lr.res},
 			)
 		}
 	}
 
+	sort.Slice(results, func(i, j int) bool { return results[i].Entry < results[j].Entry })
+	arc.SetMeta(requests.MetaKey, results)
+
 	return firstErr
 }
+
+// carry stores the baseline response to request in arc, returning its list
+// items reduced to keys.
+func carry(
+	run *cli.Run,
+	base *baseline.Baseline,
+	request requests.Request,
+	keys []string,
+	arc archive.Writer,
+) (gjson.Result, error) {
+	f, err := base.OpenEntry(cli.EntryName(request))
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	defer f.Close()
+	return cli.Carry(run, request, keys, f, arc)
+}
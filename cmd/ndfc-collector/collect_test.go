@@ -17,12 +17,34 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
 
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/baseline"
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/export"
+	"ndfc-collector/pkg/jsonstream"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/metrics"
+	"ndfc-collector/pkg/ndfc"
 	"ndfc-collector/pkg/requests"
+	"ndfc-collector/pkg/trace"
 )
 
 // --- substituteURL ---
@@ -382,45 +404,45 @@ func TestExpandLevel_MultipleParentURLs_CartesianProduct(t *testing.T) {
 	assert.Contains(t, urls, "/report/f2/s1")
 }
 
-// --- extractListResult ---
+// --- dependencyKeys ---
 
-func TestExtractListResult_WrappedObject(t *testing.T) {
-	// Typical NDFC response: the array is inside a named wrapper key.
-	// The /api/v1/manage/fabrics endpoint uses "name" (not "fabricName") per the OpenAPI schema.
-	result := gjson.Parse(`{"fabrics":[{"name":"f1"},{"name":"f2"}]}`)
-	extracted := extractListResult(result, "fabrics")
-	assert.True(t, extracted.IsArray())
-	assert.Len(t, extracted.Array(), 2)
-	assert.Equal(t, "f1", extracted.Array()[0].Get("name").String())
-}
-
-func TestExtractListResult_RootArray(t *testing.T) {
-	// list_path "@this": response is already a root array (e.g. the VRF endpoint).
-	result := gjson.Parse(`[{"id":1},{"id":2}]`)
-	extracted := extractListResult(result, "@this")
-	assert.True(t, extracted.IsArray())
-	assert.Len(t, extracted.Array(), 2)
-}
-
-func TestExtractListResult_EmptyPath(t *testing.T) {
-	// list_path "": single-object endpoint — return as-is.
-	result := gjson.Parse(`{"key":"value"}`)
-	extracted := extractListResult(result, "")
-	assert.Equal(t, result.Raw, extracted.Raw)
-}
-
-func TestExtractListResult_MissingPath(t *testing.T) {
-	// list_path points to a non-existent key — return the original result unchanged.
-	result := gjson.Parse(`{"other":"value"}`)
-	extracted := extractListResult(result, "nothere")
-	assert.Equal(t, result.Raw, extracted.Raw)
+func TestDependencyKeys(t *testing.T) {
+	reqs := []requests.Request{
+		{URL: "/fabrics"},
+		{URL: "/switches"},
+		{
+			URL: "/fabrics/{fabricName}/vrfs",
+			DependsOn: map[string]requests.Dependency{
+				"fabricName": {URL: "/fabrics", Key: "name"},
+			},
+		},
+		{
+			URL: "/report/{fabricId}/{switchId}",
+			DependsOn: map[string]requests.Dependency{
+				"fabricId": {URL: "/fabrics", Key: "id"},
+				"switchId": {URL: "/switches", Key: "serialNumber"},
+			},
+		},
+		{
+			URL: "/fabrics/{fabricName}/networks",
+			DependsOn: map[string]requests.Dependency{
+				"fabricName": {URL: "/fabrics", Key: "name"},
+			},
+		},
+	}
+	keys := dependencyKeys(reqs)
+	assert.Equal(t, map[string][]string{
+		"/fabrics":  {"id", "name"},
+		"/switches": {"serialNumber"},
+	}, keys)
 }
 
 func TestExpandLevel_VRFPipeline_WrappedFabricsResponse(t *testing.T) {
 	// Full-pipeline integration test mirroring the production VRF request config.
 	// The /api/v1/manage/fabrics endpoint returns {"fabrics":[{"name":"..."},...]}
-	// and extractListResult unwraps it before it is stored as a parentResult.
-	// expandLevel must then substitute {fabricName} from the "name" JSON field.
+	// and FetchResult projects it down to the dependency keys before it is
+	// stored as a parentResult. expandLevel must then substitute {fabricName}
+	// from the "name" JSON field.
 	levelReqs := []requests.Request{
 		{
 			URL:   "/appcenter/cisco/ndfc/api/v1/lan-fabric/rest/top-down/fabrics/{fabricName}/vrfs",
@@ -431,10 +453,11 @@ func TestExpandLevel_VRFPipeline_WrappedFabricsResponse(t *testing.T) {
 		},
 	}
 
-	rawFabricsResponse := gjson.Parse(`{"fabrics":[{"name":"DC1-FABRIC"},{"name":"DC2-FABRIC"}]}`)
-	// Simulate what collectFabric does when storing level-0 results:
-	// extractListResult unwraps the array before saving.
-	unwrapped := extractListResult(rawFabricsResponse, "fabrics")
+	rawFabricsResponse := `{"fabrics":[{"name":"DC1-FABRIC","id":1},{"name":"DC2-FABRIC","id":2}]}`
+	// Simulate what FetchResult does with level-0 responses: the list is
+	// unwrapped and reduced to the keys children depend on.
+	unwrapped, err := jsonstream.Project(strings.NewReader(rawFabricsResponse), "fabrics", []string{"name"})
+	assert.NoError(t, err)
 
 	parentResults := map[string][]parentResult{
 		"/api/v1/manage/fabrics": {
@@ -466,3 +489,365 @@ func urlsFromLevel(level []requests.Request) []string {
 	}
 	return urls
 }
+
+// --- collectFabric ---
+
+// fakeNDFC serves canned JSON bodies keyed by request path.
+func fakeNDFC(t *testing.T, bodies map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// readZip returns the content of every entry in a zip archive.
+func readZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer zr.Close()
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+func TestCollectFabric_StreamsAndExpands(t *testing.T) {
+	fabrics := `{"fabrics":[{"name":"f1","big":[1,2,3]},{"name":"f2"}]}`
+	srv := fakeNDFC(t, map[string]string{
+		"/fabrics":         fabrics,
+		"/fabrics/f1/vrfs": `[{"id":1}]`,
+		"/fabrics/f2/vrfs": `[{"id":2}]`,
+		"/infra/backups":   `{"backups":[]}`,
+	})
+	client, err := ndfc.NewClient(srv.URL, "", "")
+	require.NoError(t, err)
+
+	out := filepath.Join(t.TempDir(), "out.zip")
+	arc, err := archive.NewWriter(out)
+	require.NoError(t, err)
+
+	reqs := []requests.Request{
+		{URL: "/fabrics", DBKey: "manage/fabrics", ListPath: "fabrics"},
+		{URL: "/infra/backups", DBKey: "infra/backups", ListPath: "backups"},
+		{
+			URL:   "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/{fabricName}/vrfs",
+			DependsOn: map[string]requests.Dependency{
+				"fabricName": {URL: "/fabrics", Key: "name"},
+			},
+		},
+	}
+	run := cli.NewRun(log.New())
+	run.Trace = trace.New("ndfc-collector", "test", "collect")
+
+	cfg := config.New()
+	require.NoError(t, collectFabric(run, client, arc, reqs, nil, &cfg))
+	require.NoError(t, arc.Close())
+	run.Trace.Finish(nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, run.Trace.WriteJSON(&buf))
+	var exported struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+	spans := exported.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 7, "a root span, two level spans and four request spans")
+	ids, parents := map[string]string{}, map[string]string{}
+	for _, s := range spans {
+		ids[s.Name], parents[s.Name] = s.SpanID, s.ParentSpanID
+	}
+	assert.Equal(t, ids["collect"], parents["level 0"])
+	assert.Equal(t, ids["level 0"], parents["manage.fabrics.json"])
+	assert.Equal(t, ids["level 1"], parents["fabrics.f1.vrfs.json"], "requests are children of their level")
+
+	summary := run.Metrics.Summary()
+	assert.Equal(t, 4, summary.Requests)
+	assert.Len(t, summary.Levels, 2)
+	var vrfs metrics.Endpoint
+	for _, e := range summary.Endpoints {
+		if e.Template == "fabrics/{fabricName}/vrfs" {
+			vrfs = e
+		}
+	}
+	assert.Equal(t, 2, vrfs.Requests, "requests are measured per db_key template")
+	assert.Equal(t, int64(20), vrfs.Bytes)
+
+	files := readZip(t, out)
+	assert.Equal(t, fabrics, files["manage.fabrics.json"], "responses are stored verbatim")
+	assert.Equal(t, `[{"id":1}]`, files["fabrics.f1.vrfs.json"])
+	assert.Equal(t, `[{"id":2}]`, files["fabrics.f2.vrfs.json"])
+	assert.Contains(t, files, "infra.backups.json")
+	require.Contains(t, files, archive.ManifestName)
+
+	var m struct {
+		Meta struct {
+			Requests []requests.Result `json:"requests"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(files[archive.ManifestName]), &m))
+	require.Len(t, m.Meta.Requests, 4, "every request is recorded in the manifest")
+	assert.Equal(t, requests.Result{
+		Entry:    "fabrics.f1.vrfs.json",
+		URL:      "/fabrics/f1/vrfs",
+		Template: "/fabrics/{fabricName}/vrfs",
+		DBKey:    "fabrics/f1/vrfs",
+		Ctx:      map[string]string{"fabricName": "f1"},
+	}, m.Meta.Requests[0])
+}
+
+func TestCollectFabric_Normalize(t *testing.T) {
+	srv := fakeNDFC(t, map[string]string{
+		"/fabrics":         `{"fabrics":[{"name":"f2","id":2},{"id":1,"name":"f1"}]}`,
+		"/fabrics/f1/vrfs": `[{"id":1}]`,
+		"/fabrics/f2/vrfs": `not json`,
+	})
+	client, err := ndfc.NewClient(srv.URL, "", "")
+	require.NoError(t, err)
+
+	out := filepath.Join(t.TempDir(), "out.zip")
+	arc, err := archive.NewWriter(out)
+	require.NoError(t, err)
+
+	reqs := []requests.Request{
+		{URL: "/fabrics", DBKey: "manage/fabrics", ListPath: "fabrics", IDField: "name"},
+		{
+			URL:   "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/{fabricName}/vrfs",
+			DependsOn: map[string]requests.Dependency{
+				"fabricName": {URL: "/fabrics", Key: "name"},
+			},
+		},
+	}
+	cfg := config.New()
+	cfg.Normalize = true
+	require.NoError(t, collectFabric(cli.NewRun(log.New()), client, arc, reqs, nil, &cfg))
+	require.NoError(t, arc.Close())
+
+	files := readZip(t, out)
+	assert.Equal(t, `{
+  "fabrics": [
+    {
+      "id": 1,
+      "name": "f1"
+    },
+    {
+      "id": 2,
+      "name": "f2"
+    }
+  ]
+}
+`, files["manage.fabrics.json"])
+	assert.Contains(t, files, "fabrics.f1.vrfs.json", "normalized parents are still expanded")
+	assert.Equal(t, "not json", files["fabrics.f2.vrfs.json"], "non-JSON responses are kept as returned")
+}
+
+func TestCollectFabric_Incremental(t *testing.T) {
+	bodies := map[string]string{
+		"/fabrics":         `{"fabrics":[{"name":"f1"},{"name":"f2"}]}`,
+		"/fabrics/f1/vrfs": `[{"id":1}]`,
+		"/fabrics/f2/vrfs": `[{"id":2}]`,
+		"/infra/backups":   `{"backups":[]}`,
+	}
+	const modified = "Mon, 19 Oct 2026 02:00:00 GMT"
+	var mu sync.Mutex
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		body, ok := bodies[r.URL.Path]
+		mu.Unlock()
+		switch {
+		case !ok:
+			// Networks are sent without validators.
+			body = `[{"net":"` + r.URL.Path + `"}]`
+		case r.URL.Path == "/infra/backups":
+			w.Header().Set("Last-Modified", modified)
+			if r.Header.Get("If-Modified-Since") == modified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		default:
+			sum := sha256.Sum256([]byte(body))
+			etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	client, err := ndfc.NewClient(srv.URL, "", "")
+	require.NoError(t, err)
+
+	reqs := []requests.Request{
+		{URL: "/fabrics", DBKey: "manage/fabrics", ListPath: "fabrics"},
+		{URL: "/infra/backups", DBKey: "infra/backups", ListPath: "backups"},
+		{
+			URL:   "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/{fabricName}/vrfs",
+			DependsOn: map[string]requests.Dependency{
+				"fabricName": {URL: "/fabrics", Key: "name"},
+			},
+		},
+		{
+			URL:   "/fabrics/{fabricName}/vrfs/{vrfId}/networks",
+			DBKey: "fabrics/{fabricName}/vrfs/{vrfId}/networks",
+			DependsOn: map[string]requests.Dependency{
+				"vrfId": {URL: "/fabrics/{fabricName}/vrfs", Key: "id"},
+			},
+		},
+	}
+	cfg := config.New()
+	collect := func(base *baseline.Baseline) (string, *cli.Run) {
+		out := filepath.Join(t.TempDir(), "out.zip")
+		arc, err := archive.NewWriter(out)
+		require.NoError(t, err)
+		run := cli.NewRun(log.New())
+		require.NoError(t, collectFabric(run, client, arc, reqs, base, &cfg))
+		require.NoError(t, arc.Close())
+		return out, run
+	}
+
+	first, _ := collect(nil)
+	base, err := baseline.Open(first)
+	require.NoError(t, err)
+	require.NoError(t, base.Load())
+	defer base.Close()
+
+	// The VRFs of f1 changed although the fabric list did not.
+	mu.Lock()
+	bodies["/fabrics/f1/vrfs"] = `[{"id":1},{"id":4}]`
+	clear(hits)
+	mu.Unlock()
+
+	second, run := collect(base)
+	// Every request is sent; only NDFC decides what is unchanged.
+	assert.Equal(t, map[string]int{
+		"/fabrics":                    1,
+		"/infra/backups":              1,
+		"/fabrics/f1/vrfs":            1,
+		"/fabrics/f2/vrfs":            1,
+		"/fabrics/f1/vrfs/1/networks": 1,
+		"/fabrics/f1/vrfs/4/networks": 1,
+		"/fabrics/f2/vrfs/2/networks": 1,
+	}, hits)
+	s := run.Metrics.Summary()
+	assert.Equal(t, 3, s.Carried)
+	assert.Equal(t, 7, s.Requests, "conditional requests count as requests")
+
+	files := readZip(t, second)
+	assert.Equal(t, `{"fabrics":[{"name":"f1"},{"name":"f2"}]}`, files["manage.fabrics.json"], "not modified parent carried forward")
+	assert.Equal(t, `[{"id":1},{"id":4}]`, files["fabrics.f1.vrfs.json"], "changed child of an unchanged parent collected again")
+	assert.Equal(t, `[{"id":2}]`, files["fabrics.f2.vrfs.json"], "not modified child carried forward")
+	assert.Equal(t, `{"backups":[]}`, files["infra.backups.json"], "not modified since carried forward")
+
+	var m struct {
+		Meta struct {
+			Requests []requests.Result `json:"requests"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(files[archive.ManifestName]), &m))
+	carried := map[string]bool{}
+	for _, r := range m.Meta.Requests {
+		carried[r.Entry] = r.Carried
+		if r.Entry == "infra.backups.json" {
+			assert.Equal(t, modified, r.LastModified)
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		"manage.fabrics.json":  true,
+		"infra.backups.json":   true,
+		"fabrics.f1.vrfs.json": false,
+		"fabrics.f2.vrfs.json": true,
+
+		"fabrics.f1.vrfs.1.networks.json": false,
+		"fabrics.f1.vrfs.4.networks.json": false,
+		"fabrics.f2.vrfs.2.networks.json": false,
+	}, carried, "responses without validators are always collected again")
+}
+
+func TestCollectFabric_MovedEndpoint(t *testing.T) {
+	srv := fakeNDFC(t, map[string]string{
+		"/appcenter/fabrics":         `{"fabrics":[{"name":"f1"}]}`,
+		"/appcenter/fabrics/f1/vrfs": `[{"vrfName":"v1"}]`,
+	})
+	client, err := ndfc.NewClient(srv.URL, "", "")
+	require.NoError(t, err)
+	v12_1, err := ndfc.ParseVersion("12.1")
+	require.NoError(t, err)
+
+	catalog := []requests.Request{
+		{
+			URL:          "/api/v1/manage/fabrics",
+			DBKey:        "manage/fabrics",
+			ListPath:     "fabrics",
+			IDField:      "name",
+			Alternatives: []requests.Alternative{{URL: "/appcenter/fabrics", MaxVersion: v12_1}},
+		},
+		{
+			URL:     "/api/v1/manage/fabrics/{fabricName}/vrfs",
+			DBKey:   "fabrics/{fabricName}/vrfs",
+			IDField: "vrfName",
+			DependsOn: map[string]requests.Dependency{
+				"fabricName": {URL: "/api/v1/manage/fabrics", Key: "name"},
+			},
+			Alternatives: []requests.Alternative{
+				{URL: "/appcenter/fabrics/{fabricName}/vrfs", MaxVersion: v12_1},
+			},
+		},
+	}
+	client.Version, err = ndfc.ParseVersion("12.1.3b")
+	require.NoError(t, err)
+	reqs, skipped := requests.ForVersion(catalog, client.Version)
+	require.Empty(t, skipped)
+
+	out := filepath.Join(t.TempDir(), "out.zip")
+	arc, err := archive.NewWriter(out)
+	require.NoError(t, err)
+	cfg := config.New()
+	require.NoError(t, collectFabric(cli.NewRun(log.New()), client, arc, reqs, nil, &cfg))
+	require.NoError(t, arc.Close())
+
+	r, err := archive.Open(out)
+	require.NoError(t, err)
+	defer r.Close()
+	results, err := export.Results(r)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "/api/v1/manage/fabrics/{fabricName}/vrfs", results[0].Template,
+		"results refer to the catalog URL rather than the alternative")
+	assert.Equal(t, "/appcenter/fabrics/f1/vrfs", results[0].URL)
+
+	var ids []string
+	require.NoError(t, export.Walk(r, catalog, func(rec export.Record) error {
+		ids = append(ids, rec.Template+" "+rec.ID)
+		return nil
+	}))
+	assert.ElementsMatch(t, []string{"manage/fabrics f1", "fabrics/{fabricName}/vrfs v1"}, ids,
+		"export finds the list_path and id_field of moved requests")
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/crypt"
+	"ndfc-collector/pkg/log"
+)
+
+// DecryptCmd decrypts an archive collected with encryption enabled.
+type DecryptCmd struct {
+	Archive    string   `kong:"arg,help='Encrypted archive file'"`
+	Output     string   `kong:"short='o',help='Decrypted output file (default: archive name without .enc)'"`
+	Identity   []string `kong:"short='i',help='age identity (private key) file for public key encrypted archives'"`
+	Passphrase string   `kong:"env='NDFC_ENCRYPT_PASSPHRASE',help='Passphrase for passphrase encrypted archives'"`
+}
+
+// Run decrypts the archive. The output is removed again if decryption
+// fails part way, so a truncated or tampered archive never yields a
+// partially decrypted file.
+func (cmd *DecryptCmd) Run() error {
+	output := cmd.Output
+	if output == "" {
+		output = strings.TrimSuffix(cmd.Archive, crypt.Extension)
+		if output == cmd.Archive {
+			output += ".dec"
+		}
+	}
+
+	passphrase := cmd.Passphrase
+	if passphrase == "" && len(cmd.Identity) == 0 {
+		isPass, err := crypt.IsPassphraseEncrypted(cmd.Archive)
+		if err != nil {
+			return err
+		}
+		if isPass {
+			passphrase = config.PromptPassword("Archive passphrase:")
+		}
+	}
+
+	in, err := os.Open(cmd.Archive)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r, err := crypt.NewReader(in, cmd.Identity, passphrase)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt %s: %w", cmd.Archive, err)
+	}
+
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(output)
+		return fmt.Errorf("cannot decrypt %s: %w", cmd.Archive, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	log.Info().Msgf("Decrypted archive written to %s.", output)
+	return nil
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"ndfc-collector/pkg/export"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/brightpuddle/gobits/errors"
+)
+
+// ExportCmd converts a collection archive for ingestion by other tools.
+type ExportCmd struct {
+	Archive string `kong:"arg,help='Collection archive (zip, tar.gz, tar.zst, directory, or any volume of a split archive)'"`
+	Format  string `kong:"default='ndjson',enum='ndjson,buntdb,sqlite',help='Export format (ndjson, buntdb, sqlite)'"`
+	Output  string `kong:"short='o',default='-',help='Output file, or - for standard output (ndjson only)'"`
+}
+
+// databases maps export formats written to a database file to their
+// exporter constructors.
+var databases = map[string]func(path string) (export.Exporter, error){
+	"buntdb": func(path string) (export.Exporter, error) { return export.NewBuntDB(path) },
+	"sqlite": func(path string) (export.Exporter, error) { return export.NewSQLite(path) },
+}
+
+// Run writes one record per list item of every collected response.
+func (cmd *ExportCmd) Run() error {
+	reqs, err := requests.GetRequests()
+	if err != nil {
+		return err
+	}
+
+	var exp export.Exporter
+	if create, ok := databases[cmd.Format]; ok {
+		if cmd.Output == "-" {
+			return fmt.Errorf("the %s format needs an output file (-o)", cmd.Format)
+		}
+		if exp, err = create(cmd.Output); err != nil {
+			return err
+		}
+	} else {
+		var w io.Writer = os.Stdout
+		if cmd.Output != "-" {
+			f, err := os.Create(cmd.Output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		exp = export.NewNDJSON(w)
+	}
+
+	n, err := export.Archive(cmd.Archive, reqs, exp)
+	if err != nil {
+		if cmd.Output != "-" {
+			os.Remove(cmd.Output)
+		}
+		return fmt.Errorf("cannot export %s: %w", cmd.Archive, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d records from %s.\n", n, cmd.Archive)
+	return nil
+}
+
+// writeDB populates the database file at dbPath in format from a
+// finished collection archive, for the db_output and sqlite_output settings.
+func writeDB(archivePath string, catalog []requests.Request, format, dbPath string) error {
+	exp, err := databases[format](dbPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	n, err := export.Archive(archivePath, catalog, exp)
+	if err != nil {
+		os.Remove(dbPath)
+		return errors.WithStack(fmt.Errorf("cannot export %s: %w", archivePath, err))
+	}
+	log.Info().Msgf("Wrote %d records to %s.", n, dbPath)
+	return nil
+}
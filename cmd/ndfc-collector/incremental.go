@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"ndfc-collector/pkg/baseline"
+	"ndfc-collector/pkg/log"
+
+	"github.com/brightpuddle/gobits/errors"
+)
+
+// openBaseline loads the baseline archive of an incremental collection
+// into outputFile. The baseline must not be the output itself, which is
+// overwritten before the baseline is done with.
+func openBaseline(path, outputFile string, logger log.Logger) (*baseline.Baseline, error) {
+	in, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	out, err := filepath.Abs(outputFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if in == out {
+		return nil, errors.WithStack(fmt.Errorf("the baseline %s would be overwritten; write the collection to another file", path))
+	}
+	base, err := baseline.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(fmt.Errorf("cannot read baseline: %v", err))
+	}
+	if err := base.Load(); err != nil {
+		base.Close()
+		return nil, errors.WithStack(fmt.Errorf("cannot read baseline: %v", err))
+	}
+	logger.Info().
+		Str("baseline", path).
+		Int("depth", base.Info.Depth).
+		Msgf("Collecting incrementally against %s from %s (%d responses).",
+			base.Info.Archive, base.Info.Created.Local().Format("2006-01-02 15:04"), base.Len())
+	return base, nil
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"ndfc-collector/pkg/archive"
+)
+
+// InspectCmd summarizes a collection archive from its manifest.
+type InspectCmd struct {
+	Archive string `kong:"arg,help='Collection archive (zip, tar.gz, tar.zst, directory, or any volume of a split archive)'"`
+	Entries bool   `kong:"short='l',help='List every entry'"`
+}
+
+// Run prints the archive's metadata, volumes and, optionally, entries.
+func (cmd *InspectCmd) Run() error {
+	r, err := archive.Open(cmd.Archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	data, err := archive.ReadFile(r, archive.ManifestName)
+	if err != nil {
+		return fmt.Errorf("cannot read manifest of %s: %w", cmd.Archive, err)
+	}
+	var m archive.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("invalid manifest in %s: %w", cmd.Archive, err)
+	}
+
+	var total int64
+	for _, e := range m.Entries {
+		total += e.Size
+	}
+	fmt.Printf("Archive:  %s\n", cmd.Archive)
+	fmt.Printf("Created:  %s\n", m.Created.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("Entries:  %d (%s uncompressed)\n", len(m.Entries), formatSize(total))
+	keys := make([]string, 0, len(m.Meta))
+	for k := range m.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("%-9s %v\n", k+":", m.Meta[k])
+	}
+
+	if len(m.Parts) > 1 {
+		counts := make([]int, len(m.Parts)+1)
+		sizes := make([]int64, len(m.Parts)+1)
+		for _, e := range m.Entries {
+			if e.Part > 0 && e.Part <= len(m.Parts) {
+				counts[e.Part]++
+				sizes[e.Part] += e.Size
+			}
+		}
+		fmt.Println("Volumes:")
+		for i, part := range m.Parts {
+			fmt.Printf("  %d  %s  %d entries, %s uncompressed\n", i+1, part, counts[i+1], formatSize(sizes[i+1]))
+		}
+	}
+
+	if cmd.Entries {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\nNAME\tSIZE\tVOLUME\tSHA256")
+		for _, e := range m.Entries {
+			part := "-"
+			if e.Part > 0 {
+				part = fmt.Sprint(e.Part)
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", e.Name, e.Size, part, e.SHA256[:min(12, len(e.SHA256))])
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// formatSize renders a byte count with a decimal unit.
+func formatSize(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
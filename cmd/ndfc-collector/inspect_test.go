@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "999 B", formatSize(999))
+	assert.Equal(t, "1.0 kB", formatSize(1000))
+	assert.Equal(t, "1.5 MB", formatSize(1_500_000))
+	assert.Equal(t, "100.0 MB", formatSize(100_000_000))
+	assert.Equal(t, "2.0 GB", formatSize(2_000_000_000))
+}
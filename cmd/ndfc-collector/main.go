@@ -18,14 +18,25 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/baseline"
 	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/crypt"
+	"ndfc-collector/pkg/jobs"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/metrics"
+	"ndfc-collector/pkg/ndfc"
 	"ndfc-collector/pkg/requests"
+	"ndfc-collector/pkg/trace"
 
-	"github.com/brightpuddle/gobits/log"
+	"github.com/alecthomas/kong"
+	"github.com/brightpuddle/gobits/errors"
 )
 
 func pause(msg string) {
@@ -35,37 +46,151 @@ func pause(msg string) {
 }
 
 func main() {
-	cfg, err := readArgs()
+	var root CLI
+	ctx := kong.Parse(&root, kong.Name("ndfc-collector"), kong.Description("NDFC collector"))
+	if err := ctx.Run(); err != nil {
+		log.Fatal().Err(err).Msg("Command failed.")
+	}
+}
+
+// Run runs a collection. It is the default command.
+func (args *Args) Run() error {
+	cfg, err := readArgs(args)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Error reading configuration.")
 	}
 	if cfg == nil {
-		return // version flag
+		return nil // version flag
 	}
 
-	if cfg.Verbose {
-		log.SetLevel(log.DebugLevel)
+	if err := setupLogging(cfg); err != nil {
+		log.Fatal().Err(err).Msg("Invalid logging settings.")
+	}
+
+	if args.DryRun {
+		if err := runPlan(cfg, cli.NewRun(log.New()), os.Stdout); err != nil {
+			log.Fatal().Err(err).Msg("Planning failed.")
+		}
+		return nil
+	}
+
+	res, err := runCollection(cfg, cli.NewRun(log.New()), true)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Collection failed.")
+	}
+	log.Info().Msg("====== Complete ======")
+	res.Summary.WriteTable(os.Stdout, summaryRows)
+
+	path, err := os.Getwd()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot read current working directory")
+	}
+	outPath := filepath.Join(path, res.Archive)
+	if res.Err != nil {
+		log.Warn().Err(res.Err).Msg("some data could not be fetched")
+		log.Info().Msgf("Available data written to %s.", outPath)
 	} else {
-		log.SetLevel(log.InfoLevel)
+		log.Info().Msg("Collection complete.")
+		log.Info().Msgf("Please provide %s to Cisco Services for further analysis.", outPath)
+	}
+	if !cfg.Confirm {
+		pause("Press enter to exit.")
 	}
+	return nil
+}
+
+// runCollection collects from NDFC into the archive named by cfg.Output,
+// then writes the configured trace, databases, checks and report. Progress
+// and messages are reported to run; the progress display is only shown when
+// interactive is set. An error is returned when no archive could be
+// written.
+func runCollection(cfg *config.Config, run *cli.Run, interactive bool) (jobs.Result, error) {
+	logger := run.Log
 
 	// Initialize NDFC HTTP client
-	client, err := cli.GetClient(cfg)
+	client, err := cli.GetClient(cfg, logger)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Error initializing NDFC client.")
+		return jobs.Result{}, err
 	}
 
 	// Create results archive
 	outputFile := cfg.Output
-	arc, err := archive.NewWriter(outputFile)
+	arcMods, err := archive.ParseCompression(cfg.Compression, cfg.CompressionLevel)
+	if err != nil {
+		return jobs.Result{}, errors.WithStack(fmt.Errorf("invalid compression settings: %v", err))
+	}
+	format, err := archive.ParseFormat(cfg.Format)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("Error creating archive file: %s.", outputFile)
+		return jobs.Result{}, errors.WithStack(err)
+	}
+	if format == "" {
+		format = archive.FormatFromName(outputFile)
+	} else {
+		outputFile = archive.WithExtension(outputFile, format)
+	}
+	if len(cfg.EncryptRecipients) > 0 || cfg.EncryptPassphrase != "" {
+		if cfg.DBOutput != "" || cfg.SQLiteOutput != "" {
+			return jobs.Result{}, errors.New("db_output and sqlite_output cannot be combined with encryption; the database would hold the data unencrypted")
+		}
+		if cfg.CheckOutput != "" {
+			return jobs.Result{}, errors.New("check_output cannot be combined with encryption; run \"ndfc-collector check\" on the decrypted archive instead")
+		}
+		if cfg.ReportOutput != "" {
+			return jobs.Result{}, errors.New("report_output cannot be combined with encryption; run \"ndfc-collector report\" on the decrypted archive instead")
+		}
+		arcMods = append(arcMods, archive.Encrypt(func(w io.Writer) (io.WriteCloser, error) {
+			return crypt.NewWriter(w, cfg.EncryptRecipients, cfg.EncryptPassphrase)
+		}))
+		outputFile += crypt.Extension
+	}
+	if cfg.SignKey != "" {
+		key, err := archive.LoadPrivateKey(cfg.SignKey)
+		if err != nil {
+			return jobs.Result{}, errors.WithStack(fmt.Errorf("cannot read signing key: %v", err))
+		}
+		arcMods = append(arcMods, archive.Sign(key))
+	}
+	if cfg.VolumeSize != "" {
+		size, err := archive.ParseSize(cfg.VolumeSize)
+		if err != nil {
+			return jobs.Result{}, errors.WithStack(fmt.Errorf("invalid volume size: %v", err))
+		}
+		arcMods = append(arcMods, archive.Split(size), archive.Logger(logger))
 	}
 
 	// Initiate requests
 	reqs, err := requests.GetRequests()
 	if err != nil {
-		log.Fatal().Err(err).Msg("Error reading requests.")
+		return jobs.Result{}, errors.WithStack(fmt.Errorf("cannot read requests: %v", err))
+	}
+
+	var base *baseline.Baseline
+	if cfg.Baseline != "" {
+		if base, err = openBaseline(cfg.Baseline, outputFile, logger); err != nil {
+			return jobs.Result{}, err
+		}
+		defer base.Close()
+	}
+
+	arc, err := archive.Create(outputFile, format, arcMods...)
+	if err != nil {
+		return jobs.Result{}, errors.WithStack(fmt.Errorf("cannot create archive file %s: %v", outputFile, err))
+	}
+	arc.SetMeta(metaVersion, version)
+	if base != nil {
+		arc.SetMeta(baseline.MetaKey, base.Info)
+	}
+	if cfg.Redact {
+		arc, err = redactArchive(arc, cfg, logger)
+		if err != nil {
+			arc.Close()
+			return jobs.Result{}, errors.WithStack(fmt.Errorf("cannot configure redaction: %v", err))
+		}
+	}
+
+	catalog := reqs
+	if !client.Version.IsZero() {
+		arc.SetMeta(ndfc.VersionMetaKey, client.Version.String())
 	}
 
 	// Allow overriding in-built queries with a single endpoint query
@@ -74,28 +199,106 @@ func main() {
 			URL:   cfg.Endpoint,
 			Query: cfg.Query,
 		}}
+		arc.SetMeta(metaEndpoint, cfg.Endpoint)
+	} else {
+		var skipped []requests.Skipped
+		reqs, skipped = requests.ForVersion(reqs, client.Version)
+		if len(skipped) > 0 {
+			arc.SetMeta(requests.SkippedMetaKey, skipped)
+			logger.Info().Msgf("Skipping %d requests not available on NDFC %s.", len(skipped), client.Version)
+			for _, s := range skipped {
+				logger.Debug().Str("db_key", s.DBKey).Msgf("Skipping %s: %s.", s.URL, s.Reason)
+			}
+		}
+	}
+
+	if cfg.MetricsListen != "" {
+		stop, err := serveMetrics(cfg.MetricsListen, run.Metrics)
+		if err != nil {
+			arc.Close()
+			return jobs.Result{}, errors.WithStack(fmt.Errorf("cannot start metrics listener: %v", err))
+		}
+		defer stop()
+	}
+
+	if traceID := startTrace(cfg, run); traceID != "" {
+		arc.SetMeta(trace.MetaKey, traceID)
 	}
 
 	// Batch and fetch queries in parallel
-	collectErr := collectFabric(client, arc, reqs, cfg)
+	stopProgress := func() {}
+	if interactive {
+		stopProgress = startProgress(cfg, run)
+	}
+	collectErr := collectFabric(run, client, arc, reqs, base, cfg)
+	stopProgress()
+	run.Trace.Finish(collectErr)
+	summary := run.Metrics.Summary()
+	arc.SetMeta(metrics.MetaKey, summary)
+	if stats, ok := client.CacheStats(); ok {
+		arc.SetMeta(ndfc.CacheMetaKey, stats)
+		if stats.Hits > 0 {
+			logger.Info().Msgf("Served %d unchanged responses (%d bytes) from the response cache.", stats.Hits, stats.BytesSaved)
+		}
+	}
 
-	arc.Close()
-	log.Info().Msg("====== Complete ======")
+	if err := arc.Close(); err != nil {
+		logger.Error().Err(err).Msg("Error finishing archive.")
+	}
 
-	path, err := os.Getwd()
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot read current working directory")
+	if cfg.VolumeSize != "" {
+		if volumes := archive.Volumes(outputFile); len(volumes) > 1 {
+			logger.Info().Msgf("Archive split into %d volumes: %s.", len(volumes), strings.Join(volumes, ", "))
+		}
+	}
+	if run.Trace != nil {
+		if err := writeTrace(cfg, run); err != nil {
+			logger.Error().Err(err).Msg("Error writing trace.")
+		}
+	}
+	if cfg.DBOutput != "" {
+		if err := writeDB(outputFile, catalog, "buntdb", cfg.DBOutput); err != nil {
+			logger.Error().Err(err).Msg("Error writing database.")
+		}
+	}
+	if cfg.SQLiteOutput != "" {
+		if err := writeDB(outputFile, catalog, "sqlite", cfg.SQLiteOutput); err != nil {
+			logger.Error().Err(err).Msg("Error writing SQLite database.")
+		}
+	}
+	if cfg.CheckOutput != "" {
+		if err := writeCheckReport(outputFile, catalog, cfg.Checks, cfg.CheckOutput); err != nil {
+			logger.Error().Err(err).Msg("Error running health checks.")
+		}
+	}
+	if cfg.ReportOutput != "" {
+		if err := writeReport(outputFile, catalog, cfg.ReportOutput); err != nil {
+			logger.Error().Err(err).Msg("Error writing report.")
+		} else {
+			logger.Info().Msgf("Report written to %s.", cfg.ReportOutput)
+		}
 	}
-	outPath := filepath.Join(path, outputFile)
 
-	if collectErr != nil {
-		log.Warn().Err(collectErr).Msg("some data could not be fetched")
-		log.Info().Msgf("Available data written to %s.", outPath)
-	} else {
-		log.Info().Msg("Collection complete.")
-		log.Info().Msgf("Please provide %s to Cisco Services for further analysis.", outPath)
+	return jobs.Result{Archive: outputFile, Summary: summary, Err: collectErr}, nil
+}
+
+// setupLogging applies the log format and level. verbose is shorthand for
+// the debug level; an explicit log_level takes precedence over it.
+func setupLogging(cfg *config.Config) error {
+	if err := log.SetFormat(cfg.LogFormat); err != nil {
+		return errors.WithStack(err)
 	}
-	if !cfg.Confirm {
-		pause("Press enter to exit.")
+	level := log.InfoLevel
+	if cfg.Verbose {
+		level = log.DebugLevel
+	}
+	if cfg.LogLevel != "" {
+		var err error
+		level, err = log.ParseLevel(cfg.LogLevel)
+		if err != nil {
+			return errors.WithStack(err)
+		}
 	}
+	log.SetLevel(level)
+	return nil
 }
@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"text/tabwriter"
+
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/ndfc"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/brightpuddle/gobits/errors"
+)
+
+// plan is what a collection would request, made by a dry run.
+type plan struct {
+	Version ndfc.Version
+	Levels  [][]planStep
+	Skipped []requests.Skipped
+	Sent    int // requests sent to make the plan
+}
+
+// planStep is a request template of a plan and the requests it expands to.
+type planStep struct {
+	Request requests.Request
+	URLs    []string // resolved URLs with their query, when the parents were fetched
+	Count   int      // number of requests
+	// Estimated is set when the parents were not fetched; Count then
+	// assumes one item per parent response, i.e. one request per
+	// combination of parent requests.
+	Estimated bool
+	Err       error // why a fetched parent could not be expanded
+}
+
+// runPlan prints the plan of the collection cfg describes to w. It logs in
+// and fetches the root requests other requests depend on, but downloads
+// nothing else and writes no archive or response cache entries.
+func runPlan(cfg *config.Config, run *cli.Run, w io.Writer) error {
+	noCache := *cfg
+	noCache.Cache = false
+	cfg = &noCache
+	client, err := cli.GetClient(cfg, run.Log)
+	if err != nil {
+		return err
+	}
+	reqs, err := requests.GetRequests()
+	if err != nil {
+		return errors.WithStack(fmt.Errorf("cannot read requests: %v", err))
+	}
+	var skipped []requests.Skipped
+	if cfg.Endpoint != "all" {
+		for _, r := range reqs {
+			skipped = append(skipped, requests.Skipped{
+				URL:    r.URL,
+				DBKey:  r.DBKey,
+				Reason: "not selected by endpoint " + cfg.Endpoint,
+			})
+		}
+		reqs = []requests.Request{{URL: cfg.Endpoint, Query: cfg.Query}}
+	} else {
+		reqs, skipped = requests.ForVersion(reqs, client.Version)
+	}
+	p := buildPlan(run, client, reqs, cfg)
+	p.Version = client.Version
+	p.Skipped = skipped
+	return p.write(w)
+}
+
+// buildPlan resolves the requests of each level. Root requests that others
+// depend on are fetched, without storing their responses, so that the
+// requests of the next level are known exactly; deeper levels are
+// estimated from the number of parent requests. Requests are only skipped
+// by endpoint selection and version gates; filter entries of depends_on are
+// not evaluated, and nothing is probed beyond the version.
+func buildPlan(run *cli.Run, client ndfc.Client, reqs []requests.Request, cfg *config.Config) plan {
+	var p plan
+	depKeys := dependencyKeys(reqs)
+	parents := map[string][]parentResult{} // fetched responses by template URL
+	counts := map[string]int{}             // planned requests by template URL
+
+	for levelIdx, levelReqs := range buildLevels(reqs) {
+		var steps []planStep
+		for _, r := range levelReqs {
+			step := planStep{Request: r}
+			parentURLs := map[string]bool{}
+			for _, dep := range r.DependsOn {
+				parentURLs[dep.URL] = true
+			}
+			exact := true
+			for parentURL := range parentURLs {
+				if _, ok := parents[parentURL]; !ok {
+					exact = false
+				}
+			}
+
+			if !exact {
+				step.Estimated = true
+				step.Count = 1
+				for parentURL := range parentURLs {
+					step.Count *= counts[parentURL]
+				}
+				counts[r.URL] = step.Count
+				steps = append(steps, step)
+				continue
+			}
+
+			keys := depKeys[r.URL]
+			for _, er := range expandLevel([]requests.Request{r}, parents) {
+				step.URLs = append(step.URLs, withQuery(er.url, er.query))
+				if levelIdx > 0 || len(keys) == 0 {
+					continue
+				}
+				fetchReq := er.template
+				fetchReq.URL = er.url
+				fetchReq.DBKey = er.resolvedKey
+				fetchReq.Query = er.query
+				fetchReq.Template = er.template.DBKey
+				p.Sent++
+				resp, err := cli.FetchResult(run, client, fetchReq, keys, discardArchive{}, cfg)
+				if err != nil {
+					step.Err = err
+					continue
+				}
+				parents[r.URL] = append(parents[r.URL], parentResult{ctx: er.ctx, result: resp.Items})
+			}
+			step.Count = len(step.URLs)
+			counts[r.URL] = step.Count
+			steps = append(steps, step)
+		}
+		p.Levels = append(p.Levels, steps)
+	}
+	return p
+}
+
+// withQuery returns path with the query parameters appended.
+func withQuery(path string, query map[string]string) string {
+	if len(query) == 0 {
+		return path
+	}
+	q := url.Values{}
+	for k, v := range query {
+		q.Set(k, v)
+	}
+	return path + "?" + q.Encode()
+}
+
+// write prints the plan: a summary, the requests of each template by level,
+// the skipped requests and every resolved URL.
+func (p plan) write(w io.Writer) error {
+	var total, estimated int
+	for _, steps := range p.Levels {
+		for _, step := range steps {
+			total += step.Count
+			if step.Estimated {
+				estimated += step.Count
+			}
+		}
+	}
+	version := "an unknown release"
+	if !p.Version.IsZero() {
+		version = p.Version.String()
+	}
+	fmt.Fprintf(w, "Plan for NDFC %s: %d requests in %d levels", version, total, len(p.Levels))
+	if estimated > 0 {
+		fmt.Fprintf(w, ", about %d of them estimated", estimated)
+	}
+	fmt.Fprintf(w, "; %d skipped. Requests sent to make the plan: %d.\n", len(p.Skipped), p.Sent)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nLEVEL\tREQUESTS\tENDPOINT\tNOTE")
+	for level, steps := range p.Levels {
+		for _, step := range steps {
+			count, note := fmt.Sprint(step.Count), ""
+			switch {
+			case step.Err != nil:
+				note = "failed: " + step.Err.Error()
+			case step.Estimated:
+				count = "~" + count
+				note = "assuming one item per parent response"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", level, count, stepName(step.Request), note)
+		}
+	}
+	if len(p.Skipped) > 0 {
+		fmt.Fprintln(tw, "\nSKIPPED\tREASON")
+		for _, s := range p.Skipped {
+			name := s.DBKey
+			if name == "" {
+				name = s.URL
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", name, s.Reason)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for level, steps := range p.Levels {
+		fmt.Fprintf(w, "\nLevel %d:\n", level)
+		for _, step := range steps {
+			if step.Estimated {
+				fmt.Fprintf(w, "  %s (expanded during the collection)\n", step.Request.URL)
+				continue
+			}
+			urls := append([]string(nil), step.URLs...)
+			sort.Strings(urls)
+			for _, u := range urls {
+				fmt.Fprintf(w, "  %s\n", u)
+			}
+		}
+	}
+	return nil
+}
+
+// stepName names a request template by its db_key, or its URL if it has none.
+func stepName(r requests.Request) string {
+	if r.DBKey != "" {
+		return r.DBKey
+	}
+	return r.URL
+}
+
+// discardArchive is an archive.Writer that keeps nothing, for the
+// responses fetched to make a plan.
+type discardArchive struct{}
+
+func (discardArchive) Add(string, []byte) error { return nil }
+
+func (discardArchive) AddReader(_ string, r io.Reader) error {
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func (discardArchive) SetMeta(string, any) {}
+
+func (discardArchive) Close() error { return nil }
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/ndfc"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPlan(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = append(sent, r.URL.Path)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"fabrics":[{"name":"f1"},{"name":"f2"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	client, err := ndfc.NewClient(srv.URL, "", "")
+	require.NoError(t, err)
+
+	reqs := []requests.Request{
+		{URL: "/fabrics", DBKey: "manage/fabrics", ListPath: "fabrics"},
+		{URL: "/infra/backups", DBKey: "infra/backups", Query: map[string]string{"limit": "10"}},
+		{
+			URL:   "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/{fabricName}/vrfs",
+			DependsOn: map[string]requests.Dependency{
+				"fabricName": {URL: "/fabrics", Key: "name"},
+			},
+		},
+		{
+			URL:   "/fabrics/{fabricName}/vrfs/{vrfId}/networks",
+			DBKey: "fabrics/{fabricName}/vrfs/{vrfId}/networks",
+			DependsOn: map[string]requests.Dependency{
+				"vrfId": {URL: "/fabrics/{fabricName}/vrfs", Key: "id"},
+			},
+		},
+	}
+	cfg := config.New()
+	p := buildPlan(cli.NewRun(log.New()), client, reqs, &cfg)
+
+	assert.Equal(t, []string{"/fabrics"}, sent, "only root parents are fetched")
+	assert.Equal(t, 1, p.Sent)
+	require.Len(t, p.Levels, 3)
+	require.Len(t, p.Levels[0], 2)
+	assert.Equal(t, []string{"/infra/backups?limit=10"}, p.Levels[0][1].URLs)
+	vrfs := p.Levels[1][0]
+	assert.ElementsMatch(t, []string{"/fabrics/f1/vrfs", "/fabrics/f2/vrfs"}, vrfs.URLs)
+	assert.False(t, vrfs.Estimated)
+	networks := p.Levels[2][0]
+	assert.True(t, networks.Estimated)
+	assert.Equal(t, 2, networks.Count, "one per vrfs request")
+
+	p.Version, _ = ndfc.ParseVersion("12.1.3b")
+	p.Skipped = []requests.Skipped{{URL: "/api/v1/new", DBKey: "new", Reason: "not available on 12.1.3b (requires 12.2 or newer)"}}
+	var out bytes.Buffer
+	require.NoError(t, p.write(&out))
+	s := out.String()
+	assert.Contains(t, s, "Plan for NDFC 12.1.3b: 6 requests in 3 levels, about 2 of them estimated; 1 skipped. Requests sent to make the plan: 1.")
+	assert.Regexp(t, `2\s+~2\s+fabrics/\{fabricName\}/vrfs/\{vrfId\}/networks\s+assuming one item per parent response`, s)
+	assert.Regexp(t, `new\s+not available on 12.1.3b`, s)
+	assert.Contains(t, s, "\n  /fabrics/f2/vrfs\n")
+	assert.Contains(t, s, "\n  /fabrics/{fabricName}/vrfs/{vrfId}/networks (expanded during the collection)\n")
+}
+
+func TestRunPlan_NoCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"fabrics":[{"name":"f1"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	cfg := config.New()
+	cfg.URL = srv.URL
+	cfg.ControllerVersion = "12.2.2"
+	cfg.Cache = true
+	cfg.CacheDir = t.TempDir()
+
+	var out bytes.Buffer
+	require.NoError(t, runPlan(&cfg, cli.NewRun(log.New()), &out))
+	assert.NotEmpty(t, out.String())
+	files, err := os.ReadDir(cfg.CacheDir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "a dry run stores nothing in the response cache")
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/progress"
+
+	"golang.org/x/term"
+)
+
+// startProgress shows the progress display of run while collecting, unless
+// stdout is not a terminal or plain logging was asked for with quiet, JSON
+// logs or a level below info. Info messages are suppressed while the
+// display is shown, since it replaces them. The returned function stops the
+// display.
+func startProgress(cfg *config.Config, run *cli.Run) func() {
+	fd := int(os.Stdout.Fd())
+	level := log.GetLevel()
+	if cfg.Quiet || cfg.LogFormat == log.FormatJSON || level < log.InfoLevel || !term.IsTerminal(fd) {
+		return func() {}
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		width = 0
+	}
+	if level < log.WarnLevel {
+		log.SetLevel(log.WarnLevel)
+	}
+	run.Progress = progress.New(os.Stdout, width, run.Metrics)
+	return func() {
+		run.Progress.Stop()
+		run.Progress = nil
+		log.SetLevel(level)
+	}
+}
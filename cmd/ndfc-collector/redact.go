@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/crypt"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/redact"
+
+	"github.com/brightpuddle/gobits/errors"
+)
+
+// redactArchive wraps arc so every entry is redacted before it is written.
+// The mapping file, if requested, is encrypted with the redaction key and
+// can be read back with "ndfc-collector decrypt --passphrase <key>".
+func redactArchive(arc archive.Writer, cfg *config.Config, logger log.Logger) (archive.Writer, error) {
+	rules, err := redact.DefaultRules()
+	if cfg.RedactRules != "" {
+		rules, err = redact.LoadRules(cfg.RedactRules)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	key := []byte(cfg.RedactKey)
+	if len(key) == 0 {
+		if cfg.RedactMappingFile != "" {
+			return nil, errors.WithStack(fmt.Errorf("redact_mapping_file requires redact_key, which is used to encrypt it"))
+		}
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		logger.Warn().Msg("No redact_key set; pseudonyms will differ from other collections.")
+	}
+
+	r, err := redact.New(rules, key)
+	if err != nil {
+		return nil, errors.WithStack(fmt.Errorf("invalid redaction rules: %w", err))
+	}
+	if cfg.Normalize {
+		r.SetIndent("  ")
+	}
+	w := redact.NewWriter(arc, r)
+	if cfg.RedactMappingFile != "" {
+		w.WriteMapping(cfg.RedactMappingFile, func(w io.Writer) (io.WriteCloser, error) {
+			return crypt.NewWriter(w, nil, cfg.RedactKey)
+		})
+	}
+	return w, nil
+}
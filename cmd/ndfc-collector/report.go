@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/report"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/brightpuddle/gobits/errors"
+)
+
+// reportTitle is the heading of HTML reports.
+const reportTitle = "NDFC Collection Report"
+
+// ReportCmd renders an HTML summary of a collection archive.
+type ReportCmd struct {
+	Archive string `kong:"arg,help='Collection archive (zip, tar.gz, tar.zst, directory, or any volume of a split archive)'"`
+	Output  string `kong:"short='o',default='ndfc-collection-report.html',help='Output HTML file'"`
+}
+
+// Run writes the report.
+func (cmd *ReportCmd) Run() error {
+	reqs, err := requests.GetRequests()
+	if err != nil {
+		return err
+	}
+	if err := writeReport(cmd.Archive, reqs, cmd.Output); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Report written to %s.\n", cmd.Output)
+	return nil
+}
+
+// writeReport renders the HTML report for the archive at path to output.
+func writeReport(path string, catalog []requests.Request, output string) error {
+	r, err := archive.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer r.Close()
+	summary, err := report.Build(r, catalog)
+	if err != nil {
+		return errors.WithStack(fmt.Errorf("cannot summarize %s: %w", path, err))
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = summary.Write(f, reportTitle)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(output)
+		return errors.WithStack(err)
+	}
+	return nil
+}
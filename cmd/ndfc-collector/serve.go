@@ -0,0 +1,488 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/baseline"
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/jobs"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/schedule"
+
+	"github.com/brightpuddle/gobits/errors"
+)
+
+// Serve mode defaults.
+const (
+	defaultSchedule = "0 2 * * *" // nightly at 02:00
+	defaultListen   = "127.0.0.1:8080"
+)
+
+// scheduleOff disables scheduled collections, leaving only the job API.
+const scheduleOff = "off"
+
+// jobsDir is the directory under archive_dir for the archives of jobs
+// submitted through the API.
+const jobsDir = "jobs"
+
+// stampLayout is the collection time, in UTC, in scheduled archive names.
+const stampLayout = "20060102T150405Z"
+
+// ServeCmd runs collections on a schedule and serves the job API.
+// Collection settings come from the config file; the flags override its
+// serve settings.
+type ServeCmd struct {
+	Config      string `kong:"short='c',required,help='YAML configuration file with the collection settings'"`
+	Schedule    string `kong:"help='Cron expression for collections, e.g. 0 2 * * *, or off (default: nightly at 02:00)'"`
+	Dir         string `kong:"help='Directory for the timestamped archives (default: working directory)'"`
+	KeepDaily   int    `kong:"help='Keep the newest archive of this many days (default: keep all archives)'"`
+	KeepWeekly  int    `kong:"help='Keep the newest archive of this many weeks'"`
+	Listen      string `kong:"help='Address of the status endpoint and job API (default: 127.0.0.1:8080)'"`
+	APIToken    string `kong:"env='NDFC_API_TOKEN',help='Bearer token required by the job API'"`
+	Incremental bool   `kong:"help='Collect incrementally against the previous scheduled archive'"`
+	RunNow      bool   `kong:"help='Also collect once at startup'"`
+}
+
+// Run schedules collections and serves the job API until interrupted.
+func (cmd *ServeCmd) Run() error {
+	cfg, err := config.ParseConfig(cmd.Config)
+	if err != nil {
+		return err
+	}
+	cmd.apply(cfg)
+	if err := checkListen(cfg); err != nil {
+		return err
+	}
+	scheduled := cfg.Schedule != scheduleOff
+	if err := serveCredentials(cfg, scheduled); err != nil {
+		return err
+	}
+	if err := setupLogging(cfg); err != nil {
+		return err
+	}
+	api := &jobs.API{
+		Queue: jobs.NewQueue(collectJob, cfg.MaxJobs, cfg.MaxControllerJobs, cfg.MaxQueuedJobs),
+		Base:  cfg,
+		Dir:   filepath.Join(cfg.ArchiveDir, jobsDir),
+		Token: cfg.APIToken,
+	}
+	if err := os.MkdirAll(api.Dir, 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	mux := http.NewServeMux()
+	api.Register(mux)
+
+	var s *scheduler
+	if scheduled {
+		cron, err := schedule.Parse(cfg.Schedule)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		s = newScheduler(cfg, cron, api.Queue)
+		mux.Handle("GET /status", s)
+	}
+
+	stop, err := listen(cfg.Listen, mux)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if s == nil {
+		<-ctx.Done()
+		log.Info().Msg("Stopping.")
+		return nil
+	}
+	return s.run(ctx, cmd.RunNow)
+}
+
+// collectJob runs the collection of a queued job.
+func collectJob(cfg *config.Config, run *cli.Run) (jobs.Result, error) {
+	return runCollection(cfg, run, false)
+}
+
+// apply overrides the serve settings of cfg with the flags that were set
+// and fills in the defaults.
+func (cmd *ServeCmd) apply(cfg *config.Config) {
+	if cmd.Schedule != "" {
+		cfg.Schedule = cmd.Schedule
+	}
+	if cmd.Dir != "" {
+		cfg.ArchiveDir = cmd.Dir
+	}
+	if cmd.KeepDaily != 0 {
+		cfg.KeepDaily = cmd.KeepDaily
+	}
+	if cmd.KeepWeekly != 0 {
+		cfg.KeepWeekly = cmd.KeepWeekly
+	}
+	if cmd.Listen != "" {
+		cfg.Listen = cmd.Listen
+	}
+	if cmd.APIToken != "" {
+		cfg.APIToken = cmd.APIToken
+	}
+	if cmd.Incremental {
+		cfg.Incremental = true
+	}
+	if cfg.Schedule == "" {
+		cfg.Schedule = defaultSchedule
+	}
+	if cfg.ArchiveDir == "" {
+		cfg.ArchiveDir = "."
+	}
+	if cfg.Listen == "" {
+		cfg.Listen = defaultListen
+	}
+	cfg.Confirm = true
+	cfg.Quiet = true
+}
+
+// checkListen refuses to open the job API beyond the host without a token,
+// since anyone reaching it could start collections with the configured
+// credentials and download the archives.
+func checkListen(cfg *config.Config) error {
+	if cfg.APIToken != "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(cfg.Listen)
+	if err != nil {
+		return errors.WithStack(fmt.Errorf("invalid listen address %q: %v", cfg.Listen, err))
+	}
+	if ip := net.ParseIP(host); host == "localhost" || ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return errors.WithStack(fmt.Errorf("refusing to serve the job API on %s without api_token; set api_token (or NDFC_API_TOKEN) or listen on a loopback address", cfg.Listen))
+}
+
+// serveCredentials fills the controller and credentials missing from cfg
+// from the environment. Nobody is there to answer a prompt, so they are
+// required for scheduled collections; without those, jobs name their own
+// controller.
+func serveCredentials(cfg *config.Config, required bool) error {
+	for _, v := range []struct {
+		value *string
+		env   string
+	}{
+		{&cfg.URL, "NDFC_URL"},
+		{&cfg.Username, "NDFC_USERNAME"},
+		{&cfg.Password, "NDFC_PASSWORD"},
+	} {
+		if *v.value == "" {
+			*v.value = os.Getenv(v.env)
+		}
+		if *v.value == "" && required {
+			return errors.WithStack(fmt.Errorf(
+				"serve needs url, username and password in the config file or the %s environment variable", v.env,
+			))
+		}
+	}
+	if !required {
+		return nil
+	}
+	return cfg.NormalizeAndPrompt()
+}
+
+// serveStatus is the state reported by the status endpoint.
+type serveStatus struct {
+	State    string     `json:"state"` // idle or running
+	Schedule string     `json:"schedule"`
+	NextRun  time.Time  `json:"next_run,omitzero"`
+	Runs     int        `json:"runs"`
+	Failures int        `json:"failures"` // runs with an error or failed requests
+	LastRun  *runStatus `json:"last_run,omitempty"`
+}
+
+// runStatus describes a finished scheduled collection.
+type runStatus struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Archive  string    `json:"archive,omitempty"`
+	Requests int       `json:"requests"`
+	Failed   int       `json:"failed"`
+	Carried  int       `json:"carried,omitempty"` // responses carried forward from the baseline
+	Bytes    int64     `json:"bytes"`
+	Baseline string    `json:"baseline,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Pruned   []string  `json:"pruned,omitempty"` // archives removed by retention
+}
+
+// scheduler submits the scheduled collections of serve mode to the job
+// queue, so that they share its caps with API jobs.
+type scheduler struct {
+	cfg   *config.Config
+	cron  *schedule.Cron
+	queue *jobs.Queue
+	now   func() time.Time
+
+	mu     sync.Mutex
+	status serveStatus
+}
+
+func newScheduler(cfg *config.Config, cron *schedule.Cron, queue *jobs.Queue) *scheduler {
+	return &scheduler{
+		cfg:    cfg,
+		cron:   cron,
+		queue:  queue,
+		now:    time.Now,
+		status: serveStatus{State: "idle", Schedule: cron.String()},
+	}
+}
+
+// run collects at every scheduled time until ctx is done. A collection in
+// progress is finished first.
+func (s *scheduler) run(ctx context.Context, now bool) error {
+	if now {
+		s.collect()
+	}
+	for {
+		next := s.cron.Next(s.now())
+		if next.IsZero() {
+			return errors.WithStack(fmt.Errorf("schedule %q never runs", s.cron))
+		}
+		s.mu.Lock()
+		s.status.NextRun = next
+		s.mu.Unlock()
+		log.Info().Time("next_run", next).Msgf("Next collection at %s.", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Info().Msg("Stopping.")
+			return nil
+		case <-timer.C:
+		}
+		s.collect()
+	}
+}
+
+// collect runs one collection into a timestamped archive, then applies
+// the retention rules.
+func (s *scheduler) collect() {
+	start := s.now()
+	s.mu.Lock()
+	s.status.State = "running"
+	s.mu.Unlock()
+
+	// runCollection may modify the config, so each run gets its own copy.
+	cfg := *s.cfg
+	cfg.Output = s.archiveName(start)
+	cfg.Baseline = s.baseline()
+	job := jobs.NewJob(&cfg, "schedule", "")
+	log.Info().Str("archive", cfg.Output).Str("job", job.ID).Msg("Starting scheduled collection.")
+	err := s.queue.Submit(job)
+	var res jobs.Result
+	if err == nil {
+		<-job.Done()
+		res, err = job.Result()
+	}
+
+	st := runStatus{Start: start}
+	if cfg.Baseline != "" {
+		st.Baseline = filepath.Base(cfg.Baseline)
+	}
+	switch {
+	case err != nil:
+		st.Error = err.Error()
+		log.Error().Err(err).Msg("Scheduled collection failed.")
+	default:
+		st.Archive = res.Archive
+		st.Requests = res.Summary.Requests
+		st.Failed = res.Summary.Failures
+		st.Carried = res.Summary.Carried
+		st.Bytes = res.Summary.Bytes
+		if res.Err != nil {
+			st.Error = res.Err.Error()
+			log.Warn().Err(res.Err).Msgf("Some data could not be fetched; available data written to %s.", res.Archive)
+		} else {
+			log.Info().Msgf("Scheduled collection written to %s.", res.Archive)
+		}
+	}
+	pruned, err := s.prune()
+	if err != nil {
+		log.Error().Err(err).Msg("Error applying retention.")
+	}
+	for _, name := range pruned {
+		log.Info().Msgf("Removed %s.", name)
+	}
+	st.Pruned = pruned
+	st.End = s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.State = "idle"
+	s.status.Runs++
+	if st.Error != "" {
+		s.status.Failures++
+	}
+	s.status.LastRun = &st
+}
+
+// stem is the output file name of the config without its extension, which
+// scheduled archive names start with.
+func (s *scheduler) stem() string {
+	return filepath.Base(archive.WithExtension(s.cfg.Output, archive.FormatDir))
+}
+
+// archiveName returns the output name of a collection started at t, e.g.
+// ndfc-collection-data-20261019T020000Z.zip.
+func (s *scheduler) archiveName(t time.Time) string {
+	format := archive.Format(s.cfg.Format)
+	if format == "" {
+		format = archive.FormatFromName(s.cfg.Output)
+	}
+	name := s.stem() + "-" + t.UTC().Format(stampLayout)
+	return filepath.Join(s.cfg.ArchiveDir, archive.WithExtension(name, format))
+}
+
+// baseline returns the newest scheduled archive for an incremental
+// collection, or "" for a full one: when incremental collection is off,
+// there is no usable archive yet, or the previous full_every-1 collections
+// were already incremental.
+func (s *scheduler) baseline() string {
+	if !s.cfg.Incremental {
+		return ""
+	}
+	times, files, err := s.archives()
+	if err != nil {
+		log.Warn().Err(err).Msg("Cannot list archives; collecting in full.")
+		return ""
+	}
+	if len(times) == 0 {
+		return ""
+	}
+	newest := slices.MaxFunc(times, time.Time.Compare)
+	path := filepath.Join(s.cfg.ArchiveDir, files[newest.Unix()][0])
+	b, err := baseline.Open(path)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Cannot use %s as baseline; collecting in full.", path)
+		return ""
+	}
+	if s.cfg.FullEvery > 0 && b.Info.Depth >= s.cfg.FullEvery {
+		log.Info().Msgf("Collecting in full after %d incremental collections.", b.Info.Depth-1)
+		return ""
+	}
+	return path
+}
+
+// archives lists the scheduled archives in the archive directory by their
+// collection time, with the names of their files.
+func (s *scheduler) archives() ([]time.Time, map[int64][]string, error) {
+	entries, err := os.ReadDir(s.cfg.ArchiveDir)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	prefix := s.stem() + "-"
+	files := map[int64][]string{}
+	var times []time.Time
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || len(name) < len(prefix)+len(stampLayout) {
+			continue
+		}
+		t, err := time.Parse(stampLayout, name[len(prefix):len(prefix)+len(stampLayout)])
+		if err != nil {
+			continue
+		}
+		if _, ok := files[t.Unix()]; !ok {
+			times = append(times, t.Local())
+		}
+		files[t.Unix()] = append(files[t.Unix()], name)
+	}
+	return times, files, nil
+}
+
+// prune removes the scheduled archives not retained by keep_daily and
+// keep_weekly, together with their volumes, and returns their names.
+// Nothing is removed unless one of them is set.
+func (s *scheduler) prune() ([]string, error) {
+	if s.cfg.KeepDaily <= 0 && s.cfg.KeepWeekly <= 0 {
+		return nil, nil
+	}
+	times, files, err := s.archives()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[int64]bool{}
+	for _, t := range schedule.Retain(times, s.cfg.KeepDaily, s.cfg.KeepWeekly) {
+		keep[t.Unix()] = true
+	}
+	var pruned []string
+	for _, t := range times {
+		if keep[t.Unix()] {
+			continue
+		}
+		for _, name := range files[t.Unix()] {
+			if err := os.RemoveAll(filepath.Join(s.cfg.ArchiveDir, name)); err != nil {
+				return pruned, errors.WithStack(err)
+			}
+			pruned = append(pruned, name)
+		}
+	}
+	return pruned, nil
+}
+
+// ServeHTTP reports the status as JSON.
+func (s *scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(status)
+}
+
+// listen serves h on addr until the returned function is called.
+func listen(addr string, h http.Handler) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.WithStack(fmt.Errorf("cannot start listener: %v", err))
+	}
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Listener failed.")
+		}
+	}()
+	log.Info().Msgf("Serving status and jobs at http://%s.", ln.Addr())
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}, nil
+}
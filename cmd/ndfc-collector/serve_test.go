@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/jobs"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/schedule"
+)
+
+func testScheduler(t *testing.T, cfg *config.Config) *scheduler {
+	t.Helper()
+	(&ServeCmd{}).apply(cfg)
+	cron, err := schedule.Parse(cfg.Schedule)
+	require.NoError(t, err)
+	return newScheduler(cfg, cron, jobs.NewQueue(collectJob, cfg.MaxJobs, cfg.MaxControllerJobs, cfg.MaxQueuedJobs))
+}
+
+func TestSchedulerArchiveName(t *testing.T) {
+	cfg := config.New()
+	cfg.ArchiveDir = "archives"
+	s := testScheduler(t, &cfg)
+	at := time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC)
+	assert.Equal(t, filepath.Join("archives", "ndfc-collection-data-20261019T020000Z.zip"), s.archiveName(at))
+
+	cfg.Format = "tar.gz"
+	assert.Equal(t, filepath.Join("archives", "ndfc-collection-data-20261019T020000Z.tar.gz"), s.archiveName(at))
+
+	cfg.Format = ""
+	cfg.Output = "out/site1"
+	assert.Equal(t, filepath.Join("archives", "site1-20261019T020000Z.zip"), s.archiveName(at), "no extension is a zip file")
+
+	cfg.Output = "out/site1/"
+	assert.Equal(t, filepath.Join("archives", "site1-20261019T020000Z"), s.archiveName(at), "a trailing separator is a directory")
+}
+
+func TestSchedulerPrune(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"ndfc-collection-data-20261016T020000Z.zip",
+		"ndfc-collection-data-20261017T020000Z.zip",
+		"ndfc-collection-data-20261017T020000Z.part2.zip",
+		"ndfc-collection-data-20261018T020000Z.zip",
+		"ndfc-collection-data-20261019T020000Z.zip",
+		"ndfc-collection-data-report.html",
+		"notes.txt",
+	}
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+
+	cfg := config.New()
+	cfg.ArchiveDir = dir
+	s := testScheduler(t, &cfg)
+	pruned, err := s.prune()
+	require.NoError(t, err)
+	assert.Empty(t, pruned, "everything is kept without retention settings")
+
+	cfg.KeepDaily = 2
+	pruned, err = s.prune()
+	require.NoError(t, err)
+	sort.Strings(pruned)
+	assert.Equal(t, []string{
+		"ndfc-collection-data-20261016T020000Z.zip",
+		"ndfc-collection-data-20261017T020000Z.part2.zip",
+		"ndfc-collection-data-20261017T020000Z.zip",
+	}, pruned)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	assert.Equal(t, []string{
+		"ndfc-collection-data-20261018T020000Z.zip",
+		"ndfc-collection-data-20261019T020000Z.zip",
+		"ndfc-collection-data-report.html",
+		"notes.txt",
+	}, left)
+}
+
+func TestSchedulerCollect(t *testing.T) {
+	srv := fakeNDFC(t, map[string]string{
+		"/login":   `{}`,
+		"/fabrics": `{"fabrics":[{"name":"f1"}]}`,
+	})
+	dir := t.TempDir()
+	cfg := config.New()
+	cfg.URL = srv.URL
+	cfg.Endpoint = "/fabrics"
+	cfg.RequestRetryCount = 0
+	cfg.ArchiveDir = dir
+	cfg.CacheDir = t.TempDir()
+	cfg.KeepDaily = 1
+	s := testScheduler(t, &cfg)
+
+	stale := filepath.Join(dir, "ndfc-collection-data-20200101T000000Z.zip")
+	require.NoError(t, os.WriteFile(stale, nil, 0o644))
+
+	s.collect()
+	require.NotNil(t, s.status.LastRun)
+	last := s.status.LastRun
+	assert.Empty(t, last.Error)
+	assert.Equal(t, 1, last.Requests)
+	assert.FileExists(t, last.Archive)
+	assert.Equal(t, []string{filepath.Base(stale)}, last.Pruned)
+	assert.NoFileExists(t, stale)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+	var status serveStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, "idle", status.State)
+	assert.Equal(t, defaultSchedule, status.Schedule)
+	assert.Equal(t, 1, status.Runs)
+	assert.Equal(t, 0, status.Failures)
+	assert.Equal(t, last.Archive, status.LastRun.Archive)
+}
+
+func TestCollectJob_LogsToRun(t *testing.T) {
+	srv := fakeNDFC(t, map[string]string{
+		"/login":   `{}`,
+		"/fabrics": `{"fabrics":[{"name":"f1"}]}`,
+	})
+	cfg := config.New()
+	cfg.URL = srv.URL
+	cfg.Endpoint = "/fabrics"
+	cfg.RequestRetryCount = 0
+	cfg.Output = filepath.Join(t.TempDir(), "out.zip")
+	cfg.CacheDir = t.TempDir()
+	cfg.Redact = true
+
+	var buf bytes.Buffer
+	_, err := collectJob(&cfg, cli.NewRun(log.Tee(&buf)))
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Authenticating to NDFC", "the login is in the job log")
+	assert.Contains(t, buf.String(), "No redact_key set", "redaction warnings are in the job log")
+}
+
+func TestSchedulerIncremental(t *testing.T) {
+	srv := fakeNDFC(t, map[string]string{
+		"/login":   `{}`,
+		"/fabrics": `{"fabrics":[{"name":"f1"}]}`,
+	})
+	cfg := config.New()
+	cfg.URL = srv.URL
+	cfg.Endpoint = "/fabrics"
+	cfg.RequestRetryCount = 0
+	cfg.ArchiveDir = t.TempDir()
+	cfg.Incremental = true
+	cfg.FullEvery = 2
+	s := testScheduler(t, &cfg)
+	now := time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	var baselines []string
+	for range 3 {
+		s.collect()
+		require.Empty(t, s.status.LastRun.Error)
+		baselines = append(baselines, s.status.LastRun.Baseline)
+		now = now.Add(24 * time.Hour)
+	}
+	assert.Equal(t, []string{"", "ndfc-collection-data-20261019T020000Z.zip", ""}, baselines,
+		"the first collection and every full_every-th after it are full")
+}
+
+func TestCheckListen(t *testing.T) {
+	for listen, ok := range map[string]bool{
+		"127.0.0.1:8080": true,
+		"[::1]:8080":     true,
+		"localhost:8080": true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"10.0.0.5:8080":  false,
+		"no-port":        false,
+	} {
+		cfg := config.New()
+		cfg.Listen = listen
+		err := checkListen(&cfg)
+		assert.Equal(t, ok, err == nil, "%s: %v", listen, err)
+	}
+	cfg := config.New()
+	cfg.Listen = ":8080"
+	cfg.APIToken = "secret"
+	assert.NoError(t, checkListen(&cfg), "any address with a token")
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/trace"
+
+	"github.com/brightpuddle/gobits/errors"
+)
+
+// serviceName identifies the collector in traces.
+const serviceName = "ndfc-collector"
+
+// startTrace enables tracing of run when a trace output or endpoint is
+// configured, and returns the trace ID, or "" if tracing is off.
+func startTrace(cfg *config.Config, run *cli.Run) string {
+	if cfg.TraceOutput == "" && cfg.TraceEndpoint == "" {
+		return ""
+	}
+	run.Trace = trace.New(serviceName, version, "collect")
+	root := run.Trace.Root()
+	root.Set("ndfc.host", cfg.URL)
+	root.Set("endpoint", cfg.Endpoint)
+	root.Set("batch_size", cfg.BatchSize)
+	return run.Trace.TraceID()
+}
+
+// writeTrace writes the finished trace of run to the configured file and
+// collector.
+func writeTrace(cfg *config.Config, run *cli.Run) error {
+	if cfg.TraceOutput != "" {
+		f, err := os.Create(cfg.TraceOutput)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := run.Trace.WriteJSON(f); err != nil {
+			f.Close()
+			return errors.WithStack(err)
+		}
+		if err := f.Close(); err != nil {
+			return errors.WithStack(err)
+		}
+		run.Log.Info().Msgf("Trace written to %s.", cfg.TraceOutput)
+	}
+	if cfg.TraceEndpoint != "" {
+		if err := run.Trace.Export(cfg.TraceEndpoint); err != nil {
+			return errors.WithStack(err)
+		}
+		run.Log.Info().Str("trace_id", run.Trace.TraceID()).Msgf("Trace exported to %s.", cfg.TraceEndpoint)
+	}
+	return nil
+}
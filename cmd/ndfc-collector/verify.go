@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/jsonstream"
+	"ndfc-collector/pkg/ndfc"
+	"ndfc-collector/pkg/redact"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/tidwall/gjson"
+)
+
+// Manifest metadata recorded by the collect command.
+const (
+	metaVersion  = "collector_version"
+	metaEndpoint = "endpoint"
+)
+
+// VerifyCmd checks a collection archive for tampering, corruption and
+// missing data.
+type VerifyCmd struct {
+	Archive   string `kong:"arg,help='Collection archive (zip, tar.gz, tar.zst or directory)'"`
+	PublicKey string `kong:"help='PEM ed25519 public key the archive must be signed with'"`
+}
+
+// Run verifies every entry against the manifest hashes, the manifest
+// against its signature, and that every request in the catalog produced
+// an entry. Dependent requests are expanded from the parent responses in
+// the archive, exactly as during collection. Requests the collection left
+// out for the controller version are not expected.
+func (cmd *VerifyCmd) Run() error {
+	var trusted ed25519.PublicKey
+	if cmd.PublicKey != "" {
+		var err error
+		if trusted, err = archive.LoadPublicKey(cmd.PublicKey); err != nil {
+			return err
+		}
+	}
+	reqs, err := requests.GetRequests()
+	if err != nil {
+		return err
+	}
+
+	r, err := archive.Open(cmd.Archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// The manifest is read ahead to select the requests for the controller
+	// version; problems with it are reported by the verification below.
+	notCollected := 0
+	if data, err := archive.ReadFile(r, archive.ManifestName); err == nil {
+		var m archive.Manifest
+		if json.Unmarshal(data, &m) == nil {
+			n := len(reqs)
+			reqs = collectedRequests(reqs, m.Meta)
+			notCollected = n - len(reqs)
+		}
+	}
+
+	cat := newCatalog(reqs)
+	v, err := archive.Verify(r, trusted, cat.observe)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", cmd.Archive, err)
+	}
+
+	fmt.Printf("Archive:   %s\n", cmd.Archive)
+	if v.Manifest != nil {
+		fmt.Printf("Created:   %s\n", v.Manifest.Created.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Entries:   %d\n", len(v.Manifest.Entries))
+	}
+	switch {
+	case v.Signer == nil:
+		fmt.Println("Signature: none")
+	case trusted != nil:
+		fmt.Printf("Signature: valid, trusted key %s\n", archive.Fingerprint(v.Signer))
+	default:
+		fmt.Printf("Signature: valid, key %s (use --public-key to require a trusted key)\n",
+			archive.Fingerprint(v.Signer))
+	}
+
+	var missing []string
+	if v.Manifest != nil {
+		meta := v.Manifest.Meta
+		switch {
+		case meta[metaEndpoint] != nil:
+			fmt.Printf("Catalog:   skipped, single endpoint collection of %v\n", meta[metaEndpoint])
+		default:
+			if ver, ok := meta[metaVersion]; ok && ver != version {
+				fmt.Printf("Catalog:   collected by version %v, checking against %s\n", ver, version)
+			}
+			redacted, _ := meta[redact.MetaKey].(bool)
+			missing = cat.missing(redacted)
+			fmt.Printf("Catalog:   %d expected entries, %d missing\n", cat.expected, len(missing))
+			if notCollected > 0 {
+				fmt.Printf("           %d requests skipped for the controller version\n", notCollected)
+			}
+			if redacted {
+				fmt.Println("           dependent requests not checked in redacted archives")
+			}
+		}
+	}
+
+	for _, p := range v.Problems {
+		fmt.Println("FAIL", p)
+	}
+	for _, name := range missing {
+		fmt.Println("MISSING", name)
+	}
+	if !v.OK() {
+		return fmt.Errorf("%s failed verification", cmd.Archive)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s is incomplete", cmd.Archive)
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+// collectedRequests narrows the catalog to the requests made by the
+// collection described by meta: those available on the recorded controller
+// version, less any recorded as skipped.
+func collectedRequests(reqs []requests.Request, meta map[string]any) []requests.Request {
+	if s, ok := meta[ndfc.VersionMetaKey].(string); ok {
+		if v, err := ndfc.ParseVersion(s); err == nil {
+			reqs, _ = requests.ForVersion(reqs, v)
+		}
+	}
+	list, _ := meta[requests.SkippedMetaKey].([]any)
+	if len(list) == 0 {
+		return reqs
+	}
+	skipped := map[string]bool{}
+	for _, s := range list {
+		if s, ok := s.(map[string]any); ok {
+			if url, ok := s["url"].(string); ok {
+				skipped[url] = true
+			}
+		}
+	}
+	var selected []requests.Request
+	for _, r := range reqs {
+		if !skipped[r.URL] {
+			selected = append(selected, r)
+		}
+	}
+	return selected
+}
+
+// parentTemplate matches the entry names produced by a request whose
+// responses other requests depend on.
+type parentTemplate struct {
+	req requests.Request
+	re  *regexp.Regexp
+}
+
+// catalog tracks which catalog entries are present in an archive.
+type catalog struct {
+	reqs      []requests.Request
+	depKeys   map[string][]string
+	parents   []parentTemplate
+	present   map[string]bool
+	projected map[string]map[string]gjson.Result // template URL -> entry name -> items
+	expected  int
+}
+
+func newCatalog(reqs []requests.Request) *catalog {
+	c := &catalog{
+		reqs:      reqs,
+		depKeys:   dependencyKeys(reqs),
+		present:   map[string]bool{},
+		projected: map[string]map[string]gjson.Result{},
+	}
+	for _, r := range reqs {
+		if len(c.depKeys[r.URL]) == 0 {
+			continue
+		}
+		c.parents = append(c.parents, parentTemplate{r, entryPattern(cli.EntryName(r))})
+	}
+	return c
+}
+
+// entryPattern matches the entry names a templated name resolves to.
+// Placeholder values may contain anything, including dots.
+func entryPattern(name string) *regexp.Regexp {
+	parts := placeholderRe.Split(name, -1)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".+") + "$")
+}
+
+// observe records an archive entry, keeping the dependency keys of any
+// parent response for expanding the requests that depend on it.
+func (c *catalog) observe(name string, r io.Reader) error {
+	c.present[name] = true
+	for _, p := range c.parents {
+		if !p.re.MatchString(name) {
+			continue
+		}
+		res, err := jsonstream.Project(bufio.NewReader(r), p.req.ListPath, c.depKeys[p.req.URL])
+		if err != nil {
+			return nil // not JSON; its dependents are reported missing
+		}
+		if c.projected[p.req.URL] == nil {
+			c.projected[p.req.URL] = map[string]gjson.Result{}
+		}
+		c.projected[p.req.URL][name] = res
+		return nil
+	}
+	return nil
+}
+
+// missing returns the catalog entries absent from the archive, in
+// collection order. With rootsOnly, requests depending on other responses
+// are not checked.
+func (c *catalog) missing(rootsOnly bool) []string {
+	var missing []string
+	c.expected = 0
+	parentResults := map[string][]parentResult{}
+	for depth, level := range buildLevels(c.reqs) {
+		if rootsOnly && depth > 0 {
+			break
+		}
+		for _, er := range expandLevel(level, parentResults) {
+			req := er.template
+			req.URL = er.url
+			req.DBKey = er.resolvedKey
+			name := cli.EntryName(req)
+			c.expected++
+			if !c.present[name] {
+				missing = append(missing, name)
+				continue
+			}
+			if res, ok := c.projected[er.template.URL][name]; ok {
+				parentResults[er.template.URL] = append(parentResults[er.template.URL],
+					parentResult{ctx: er.ctx, result: res})
+			}
+		}
+	}
+	return missing
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/ndfc"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryPattern(t *testing.T) {
+	re := entryPattern("fabrics.{fabricName}.switches.{serial}.json")
+	assert.True(t, re.MatchString("fabrics.f1.switches.FDO1.json"))
+	assert.True(t, re.MatchString("fabrics.site.a.switches.FDO1.json"))
+	assert.False(t, re.MatchString("fabrics.f1.vrfs.json"))
+	assert.False(t, re.MatchString("fabricsXf1.switches.FDO1.json"))
+}
+
+func TestCatalog_Missing(t *testing.T) {
+	reqs := []requests.Request{
+		{URL: "/fabrics", DBKey: "manage/fabrics", ListPath: "fabrics"},
+		{URL: "/infra/backups", DBKey: "infra/backups"},
+		{
+			URL:      "/fabrics/{fabricName}/switches",
+			DBKey:    "fabrics/{fabricName}/switches",
+			ListPath: "@this",
+			DependsOn: map[string]requests.Dependency{
+				"fabricName": {URL: "/fabrics", Key: "name"},
+			},
+		},
+		{
+			URL:   "/fabrics/{fabricName}/switches/{serial}/interfaces",
+			DBKey: "fabrics/{fabricName}/switches/{serial}/interfaces",
+			DependsOn: map[string]requests.Dependency{
+				"serial": {URL: "/fabrics/{fabricName}/switches", Key: "serialNumber"},
+			},
+		},
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	arc, err := archive.NewDirWriter(dir)
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("manage.fabrics.json", []byte(`{"fabrics":[{"name":"f1"},{"name":"f2"}]}`)))
+	require.NoError(t, arc.Add("fabrics.f1.switches.json", []byte(`[{"serialNumber":"S1"},{"serialNumber":"S2"}]`)))
+	require.NoError(t, arc.Add("fabrics.f1.switches.S1.interfaces.json", []byte(`[]`)))
+	require.NoError(t, arc.Close())
+
+	r, err := archive.Open(dir)
+	require.NoError(t, err)
+	defer r.Close()
+	cat := newCatalog(reqs)
+	v, err := archive.Verify(r, nil, cat.observe)
+	require.NoError(t, err)
+	require.True(t, v.OK(), v.Problems)
+
+	assert.Equal(t, []string{
+		"infra.backups.json",
+		"fabrics.f2.switches.json",
+		"fabrics.f1.switches.S2.interfaces.json",
+	}, cat.missing(false))
+	assert.Equal(t, 6, cat.expected)
+
+	assert.Equal(t, []string{"infra.backups.json"}, cat.missing(true))
+	assert.Equal(t, 2, cat.expected)
+}
+
+func TestCatalog_ControllerVersion(t *testing.T) {
+	v12_2, err := ndfc.ParseVersion("12.2")
+	require.NoError(t, err)
+	reqs := []requests.Request{
+		{URL: "/fabrics", DBKey: "manage/fabrics"},
+		{URL: "/api/v1/new", DBKey: "new", MinVersion: v12_2},
+		{URL: "/infra/backups", DBKey: "infra/backups"},
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	arc, err := archive.NewDirWriter(dir)
+	require.NoError(t, err)
+	arc.SetMeta(ndfc.VersionMetaKey, "12.1.3b")
+	arc.SetMeta(requests.SkippedMetaKey, []requests.Skipped{
+		{URL: "/infra/backups", DBKey: "infra/backups", Reason: "not available"},
+	})
+	require.NoError(t, arc.Add("manage.fabrics.json", []byte(`[]`)))
+	require.NoError(t, arc.Close())
+
+	r, err := archive.Open(dir)
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := archive.ReadFile(r, archive.ManifestName)
+	require.NoError(t, err)
+	var m archive.Manifest
+	require.NoError(t, json.Unmarshal(data, &m))
+
+	assert.Equal(t, []string{"new.json", "infra.backups.json"}, missingEntries(t, r, reqs),
+		"the full catalog expects the gated and skipped requests")
+	assert.Empty(t, missingEntries(t, r, collectedRequests(reqs, m.Meta)))
+}
+
+// missingEntries verifies r against reqs and returns the missing entries.
+func missingEntries(t *testing.T, r archive.Reader, reqs []requests.Request) []string {
+	t.Helper()
+	cat := newCatalog(reqs)
+	v, err := archive.Verify(r, nil, cat.observe)
+	require.NoError(t, err)
+	require.True(t, v.OK(), v.Problems)
+	return cat.missing(false)
+}
@@ -18,54 +18,286 @@ package archive
 
 import (
 	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 )
 
-var zipMux sync.Mutex
-
 // Writer is an archive writer interface
 type Writer interface {
 	Add(string, []byte) error
+	AddReader(string, io.Reader) error
+	SetMeta(string, any)
 	Close() error
 }
 
-// FileWriter is a file-based implementation of archiveWriter
+// Format is an archive output format.
+// Every format stores the same entries and manifest.
+type Format string
+
+// Supported archive formats.
+const (
+	FormatZip    Format = "zip"
+	FormatTarGz  Format = "tar.gz"
+	FormatTarZst Format = "tar.zst"
+	FormatDir    Format = "dir"
+)
+
+// extensions maps file extensions to formats, longest first.
+var extensions = []struct {
+	ext    string
+	format Format
+}{
+	{".tar.gz", FormatTarGz},
+	{".tar.zst", FormatTarZst},
+	{".tgz", FormatTarGz},
+	{".tzst", FormatTarZst},
+	{".zip", FormatZip},
+}
+
+// ParseFormat validates a format name as used in the config file and CLI.
+// An empty string is returned unchanged, meaning the format should be
+// inferred from the output file name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "", FormatZip, FormatTarGz, FormatTarZst, FormatDir:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown archive format %q", s)
+}
+
+// FormatFromName infers the archive format from a file name's extension.
+// A name ending in a path separator is written as a directory; names
+// without a recognised archive extension are written as zip files.
+func FormatFromName(name string) Format {
+	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, string(filepath.Separator)) {
+		return FormatDir
+	}
+	lower := strings.ToLower(name)
+	for _, e := range extensions {
+		if strings.HasSuffix(lower, e.ext) {
+			return e.format
+		}
+	}
+	return FormatZip
+}
+
+// WithExtension returns name with its archive extension replaced by the
+// conventional extension for format, e.g.
+//
+//	WithExtension("ndfc-collection-data.zip", FormatTarGz) // ndfc-collection-data.tar.gz
+func WithExtension(name string, format Format) string {
+	lower := strings.ToLower(name)
+	for _, e := range extensions {
+		if strings.HasSuffix(lower, e.ext) {
+			name = name[:len(name)-len(e.ext)]
+			break
+		}
+	}
+	if format == FormatDir {
+		return name
+	}
+	return name + "." + string(format)
+}
+
+// Create creates an archive writer for name in the given format.
+// Compression options apply to zip and tar formats; a directory is
+// written uncompressed. With the Split option a zip archive is written as
+// a set of volumes.
+func Create(name string, format Format, opts ...Option) (Writer, error) {
+	o := newOptions(opts)
+	if o.split > 0 {
+		if format != FormatZip {
+			return nil, fmt.Errorf("only zip archives can be split into volumes, not %s", format)
+		}
+		return NewSplitWriter(name, o.split, opts...)
+	}
+	if format == FormatDir {
+		if o.encrypt != nil {
+			return nil, errors.New("directory output cannot be encrypted")
+		}
+		return NewDirWriter(name, opts...)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	var out io.WriteCloser = f
+	if o.encrypt != nil {
+		enc, err := o.encrypt(f)
+		if err != nil {
+			f.Close()
+			os.Remove(name)
+			return nil, err
+		}
+		out = encryptedFile{WriteCloser: enc, file: f}
+	}
+	switch format {
+	case FormatZip:
+		return newZipWriter(out, opts...), nil
+	case FormatTarGz, FormatTarZst:
+		return newTarWriter(out, format, opts...)
+	}
+	f.Close()
+	os.Remove(name)
+	return nil, fmt.Errorf("unknown archive format %q", format)
+}
+
+// ErrClosed is returned when adding to an archive that has been closed.
+var ErrClosed = errors.New("archive is closed")
+
+// FileWriter is a file-based zip implementation of Writer. A zip file is a
+// single stream, so entries are written one at a time by a dedicated
+// goroutine that owns the zip writer and manifest; callers hand it work
+// over a channel. Each FileWriter is independent, so several archives can
+// be written concurrently in one process.
 type FileWriter struct {
-	file *os.File
-	zw   *zip.Writer
+	mu     sync.RWMutex // held for reading while sending ops, for writing by Close
+	closed bool
+	ops    chan func()
+	done   chan struct{}
+
+	// Owned by the writer goroutine.
+	out      io.WriteCloser
+	zw       *zip.Writer
+	manifest *Manifest
+	method   uint16
+	sign     ed25519.PrivateKey
 }
 
-// NewWriter creates a new file-based archive writer
-func NewWriter(name string) (Writer, error) {
+// NewWriter creates a new file-based zip archive writer.
+// Pass options in to control compression, e.g.
+//
+//	arc, _ := NewWriter("out.zip", Compression(Zstd), CompressionLevel(LevelBest))
+func NewWriter(name string, opts ...Option) (*FileWriter, error) {
 	f, err := os.Create(name)
 	if err != nil {
-		return FileWriter{}, err
+		return nil, err
+	}
+	return newZipWriter(f, opts...), nil
+}
+
+// newZipWriter creates a zip archive writer on top of out and starts its
+// writer goroutine, which exits when the archive is closed.
+func newZipWriter(out io.WriteCloser, opts ...Option) *FileWriter {
+	o := newOptions(opts)
+	a := &FileWriter{
+		ops:      make(chan func()),
+		done:     make(chan struct{}),
+		out:      out,
+		zw:       zip.NewWriter(out),
+		manifest: newManifest(),
+		method:   o.method,
+		sign:     o.sign,
+	}
+	o.registerCompressors(a.zw)
+	go a.run()
+	return a
+}
+
+// run executes ops in the order they are received.
+func (a *FileWriter) run() {
+	defer close(a.done)
+	for op := range a.ops {
+		op()
 	}
-	zw := zip.NewWriter(f)
-	return FileWriter{
-		file: f,
-		zw:   zw,
-	}, nil
 }
 
-// Close closes the zip writer and file
-func (a FileWriter) Close() error {
-	err := a.zw.Close()
+// do runs op on the writer goroutine and waits for it to finish.
+func (a *FileWriter) do(op func() error) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrClosed
+	}
+	errc := make(chan error, 1)
+	a.ops <- func() { errc <- op() }
+	return <-errc
+}
+
+// SetMeta records a collection-level value in the manifest.
+func (a *FileWriter) SetMeta(key string, value any) {
+	_ = a.do(func() error {
+		a.manifest.setMeta(key, value)
+		return nil
+	})
+}
+
+// Close waits for pending entries, writes the manifest, signing it if
+// requested, and closes the zip writer and file. Later calls return
+// ErrClosed.
+func (a *FileWriter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return ErrClosed
+	}
+	a.closed = true
+	close(a.ops)
+	<-a.done
+
+	files, err := a.manifest.trailer(a.sign)
 	if err != nil {
 		return err
 	}
-	return a.file.Close()
+	for _, f := range files {
+		if _, err := a.add(f.name, bytes.NewReader(f.data)); err != nil {
+			return err
+		}
+	}
+	if err := a.zw.Close(); err != nil {
+		return err
+	}
+	return a.out.Close()
 }
 
 // Add adds a file and content to the zip archive
-func (a FileWriter) Add(name string, content []byte) error {
-	zipMux.Lock()
-	defer zipMux.Unlock()
-	f, err := a.zw.Create(name)
+func (a *FileWriter) Add(name string, content []byte) error {
+	return a.AddReader(name, bytes.NewReader(content))
+}
+
+// AddReader streams the content of r into a new file in the zip archive,
+// recording its size and SHA-256 hash in the manifest.
+func (a *FileWriter) AddReader(name string, r io.Reader) error {
+	return a.do(func() error {
+		entry, err := a.add(name, r)
+		if err != nil {
+			return err
+		}
+		a.manifest.Entries = append(a.manifest.Entries, entry)
+		return nil
+	})
+}
+
+// add writes a single zip entry. It runs on the writer goroutine, or in
+// Close once that has exited.
+func (a *FileWriter) add(name string, r io.Reader) (Entry, error) {
+	name, err := cleanName(name)
 	if err != nil {
-		return err
+		return Entry{}, err
+	}
+	f, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   a.method,
+		Modified: entryTime,
+	})
+	if err != nil {
+		return Entry{}, err
+	}
+	return copyEntry(name, f, r)
+}
+
+// cleanName rejects entry names that would escape the archive root.
+func cleanName(name string) (string, error) {
+	clean := filepath.ToSlash(filepath.Clean(name))
+	if clean == "." || strings.HasPrefix(clean, "../") || clean == ".." || filepath.IsAbs(name) {
+		return "", fmt.Errorf("invalid archive entry name %q", name)
 	}
-	_, err = f.Write(content)
-	return err
+	return clean, nil
 }
@@ -17,10 +17,32 @@
 package archive
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"ndfc-collector/pkg/crypt"
+	"ndfc-collector/pkg/log"
+
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewWriter(t *testing.T) {
@@ -43,3 +65,701 @@ func TestNewWriter(t *testing.T) {
 	_, err = os.Stat(tmpfile)
 	assert.NoError(t, err)
 }
+
+func TestManifest(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "test-archive.zip")
+
+	arc, err := NewWriter(tmpfile)
+	require.NoError(t, err)
+	require.NoError(t, arc.AddReader("a.json", strings.NewReader(`{"a":1}`)))
+	require.NoError(t, arc.Add("b.json", []byte(`[]`)))
+	require.NoError(t, arc.Close())
+
+	zr, err := zip.OpenReader(tmpfile)
+	require.NoError(t, err)
+	defer zr.Close()
+
+	f, err := zr.Open(ManifestName)
+	require.NoError(t, err)
+	defer f.Close()
+	var m Manifest
+	require.NoError(t, json.NewDecoder(f).Decode(&m))
+
+	sum := sha256.Sum256([]byte(`{"a":1}`))
+	assert.Equal(t, []Entry{
+		{Name: "a.json", Size: 7, SHA256: hex.EncodeToString(sum[:])},
+		{Name: "b.json", Size: 2, SHA256: "4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"},
+	}, m.Entries)
+	assert.False(t, m.Created.IsZero())
+}
+
+func TestFileWriter_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	arcs := make([]*FileWriter, 2)
+	for i := range arcs {
+		var err error
+		arcs[i], err = NewWriter(filepath.Join(dir, fmt.Sprintf("out%d.zip", i)))
+		require.NoError(t, err)
+	}
+
+	// Writers are independent: both archives are written at once, each
+	// from many goroutines.
+	var wg sync.WaitGroup
+	for i, arc := range arcs {
+		for j := range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, arc.Add(fmt.Sprintf("%d/%d.json", i, j), []byte(strings.Repeat("x", j*100))))
+			}()
+		}
+	}
+	wg.Wait()
+
+	for i, arc := range arcs {
+		require.NoError(t, arc.Close())
+		zr, err := zip.OpenReader(filepath.Join(dir, fmt.Sprintf("out%d.zip", i)))
+		require.NoError(t, err)
+		assert.Len(t, zr.File, 21, "20 entries and the manifest")
+		zr.Close()
+	}
+}
+
+func TestFileWriter_Closed(t *testing.T) {
+	arc, err := NewWriter(filepath.Join(t.TempDir(), "out.zip"))
+	require.NoError(t, err)
+	require.NoError(t, arc.Close())
+	assert.ErrorIs(t, arc.Add("late.json", []byte(`{}`)), ErrClosed)
+	assert.ErrorIs(t, arc.Close(), ErrClosed)
+	arc.SetMeta("ignored", true)
+}
+
+func TestCompressionOptions(t *testing.T) {
+	content := []byte(strings.Repeat(`{"switchName":"leaf1","serialNumber":"SN1"},`, 200))
+	cases := []struct {
+		name   string
+		mods   []Option
+		method uint16
+	}{
+		{"default", nil, Deflate},
+		{"deflate-best", []Option{CompressionLevel(LevelBest)}, Deflate},
+		{"store-level", []Option{CompressionLevel(LevelStore)}, Store},
+		{"store-method", []Option{Compression(Store)}, Store},
+		{"zstd-fast", []Option{Compression(Zstd), CompressionLevel(LevelFast)}, Zstd},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpfile := filepath.Join(t.TempDir(), "test-archive.zip")
+			arc, err := NewWriter(tmpfile, tc.mods...)
+			require.NoError(t, err)
+			require.NoError(t, arc.Add("test.json", content))
+			require.NoError(t, arc.Close())
+
+			zr, err := zip.OpenReader(tmpfile)
+			require.NoError(t, err)
+			defer zr.Close()
+			RegisterDecompressors(&zr.Reader)
+
+			f := zr.File[0]
+			assert.Equal(t, tc.method, f.Method)
+			rc, err := f.Open()
+			require.NoError(t, err)
+			defer rc.Close()
+			got, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			assert.Equal(t, content, got)
+		})
+	}
+}
+
+func TestRegisterCompressor(t *testing.T) {
+	called := false
+	comp := func(w io.Writer) (io.WriteCloser, error) {
+		called = true
+		return flate.NewWriter(w, flate.BestSpeed)
+	}
+	tmpfile := filepath.Join(t.TempDir(), "test-archive.zip")
+	arc, err := NewWriter(tmpfile, RegisterCompressor(Deflate, comp))
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("test.json", []byte("{}")))
+	require.NoError(t, arc.Close())
+	assert.True(t, called)
+}
+
+func TestParseCompression(t *testing.T) {
+	_, err := ParseCompression("zstd", "best")
+	assert.NoError(t, err)
+	_, err = ParseCompression("", "")
+	assert.NoError(t, err)
+	_, err = ParseCompression("lzma", "")
+	assert.Error(t, err)
+	_, err = ParseCompression("deflate", "ultra")
+	assert.Error(t, err)
+}
+
+func TestFormatFromName(t *testing.T) {
+	assert.Equal(t, FormatZip, FormatFromName("ndfc-collection-data.zip"))
+	assert.Equal(t, FormatZip, FormatFromName("OUT.ZIP"))
+	assert.Equal(t, FormatTarGz, FormatFromName("out.tar.gz"))
+	assert.Equal(t, FormatTarGz, FormatFromName("out.tgz"))
+	assert.Equal(t, FormatTarZst, FormatFromName("out.tar.zst"))
+	assert.Equal(t, FormatZip, FormatFromName("collection"), "unknown extensions fall back to zip")
+	assert.Equal(t, FormatZip, FormatFromName("collection.data"))
+	assert.Equal(t, FormatDir, FormatFromName("collection/"))
+	assert.Equal(t, FormatDir, FormatFromName("out"+string(filepath.Separator)))
+}
+
+func TestWithExtension(t *testing.T) {
+	assert.Equal(t, "data.tar.gz", WithExtension("data.zip", FormatTarGz))
+	assert.Equal(t, "data.zip", WithExtension("data.tar.zst", FormatZip))
+	assert.Equal(t, "data", WithExtension("data.zip", FormatDir))
+	assert.Equal(t, "data.tar.zst", WithExtension("data", FormatTarZst))
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("tar.zst")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatTarZst, f)
+	_, err = ParseFormat("rar")
+	assert.Error(t, err)
+}
+
+// readTar returns the content of every entry in a compressed tarball.
+func readTar(t *testing.T, path string, format Format) map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var r io.Reader
+	if format == FormatTarZst {
+		zr, err := zstd.NewReader(f)
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	} else {
+		gr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		defer gr.Close()
+		r = gr
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(data)
+	}
+	return files
+}
+
+func TestCreate_Formats(t *testing.T) {
+	spool := filepath.Join(t.TempDir(), "spool.json")
+	require.NoError(t, os.WriteFile(spool, []byte(`{"spooled":true}`), 0o600))
+
+	for _, format := range []Format{FormatZip, FormatTarGz, FormatTarZst, FormatDir} {
+		t.Run(string(format), func(t *testing.T) {
+			name := WithExtension(filepath.Join(t.TempDir(), "out"), format)
+			arc, err := Create(name, format, CompressionLevel(LevelBest))
+			require.NoError(t, err)
+
+			require.NoError(t, arc.Add("a.json", []byte(`{"a":1}`)))
+			f, err := os.Open(spool)
+			require.NoError(t, err)
+			require.NoError(t, arc.AddReader("b.json", f))
+			f.Close()
+			// Readers of unknown length are spooled before being added.
+			require.NoError(t, arc.AddReader("c.json", io.MultiReader(strings.NewReader(`[`), strings.NewReader(`]`))))
+			require.NoError(t, arc.Close())
+
+			var files map[string]string
+			switch format {
+			case FormatZip:
+				files = map[string]string{}
+				zr, err := zip.OpenReader(name)
+				require.NoError(t, err)
+				defer zr.Close()
+				for _, zf := range zr.File {
+					rc, err := zf.Open()
+					require.NoError(t, err)
+					data, _ := io.ReadAll(rc)
+					rc.Close()
+					files[zf.Name] = string(data)
+				}
+			case FormatDir:
+				files = map[string]string{}
+				entries, err := os.ReadDir(name)
+				require.NoError(t, err)
+				for _, e := range entries {
+					data, err := os.ReadFile(filepath.Join(name, e.Name()))
+					require.NoError(t, err)
+					files[e.Name()] = string(data)
+				}
+			default:
+				files = readTar(t, name, format)
+			}
+
+			assert.Equal(t, `{"a":1}`, files["a.json"])
+			assert.Equal(t, `{"spooled":true}`, files["b.json"])
+			assert.Equal(t, `[]`, files["c.json"])
+
+			var m Manifest
+			require.NoError(t, json.Unmarshal([]byte(files[ManifestName]), &m))
+			require.Len(t, m.Entries, 3)
+			assert.Equal(t, "b.json", m.Entries[1].Name)
+			assert.Equal(t, int64(16), m.Entries[1].Size)
+		})
+	}
+}
+
+func TestCreate_RejectsEscapingNames(t *testing.T) {
+	for _, format := range []Format{FormatZip, FormatTarGz, FormatTarZst, FormatDir} {
+		t.Run(string(format), func(t *testing.T) {
+			name := WithExtension(filepath.Join(t.TempDir(), "out"), format)
+			arc, err := Create(name, format)
+			require.NoError(t, err)
+			assert.Error(t, arc.Add("../escape.json", []byte("{}")))
+			assert.Error(t, arc.Add("/abs.json", []byte("{}")))
+			require.NoError(t, arc.Add("sub/../a.json", []byte("{}")))
+			require.NoError(t, arc.Close())
+
+			r, err := Open(name)
+			require.NoError(t, err)
+			defer r.Close()
+			v, err := Verify(r, nil, nil)
+			require.NoError(t, err)
+			assert.True(t, v.OK(), v.Problems)
+			assert.Equal(t, "a.json", v.Manifest.Entries[0].Name, "names are cleaned")
+		})
+	}
+}
+
+func TestCreate_Encrypted(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out.zip.enc")
+	encrypt := Encrypt(func(w io.Writer) (io.WriteCloser, error) {
+		return crypt.NewWriter(w, nil, "pw")
+	})
+	arc, err := Create(name, FormatZip, encrypt)
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("a.json", []byte(`{"a":1}`)))
+	require.NoError(t, arc.Close())
+
+	_, err = zip.OpenReader(name)
+	assert.Error(t, err, "ciphertext should not be a readable zip")
+
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := crypt.NewReader(f, nil, "pw")
+	require.NoError(t, err)
+	plain, err := io.ReadAll(r)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(plain), int64(len(plain)))
+	require.NoError(t, err)
+	assert.Equal(t, "a.json", zr.File[0].Name)
+
+	_, err = Create(filepath.Join(t.TempDir(), "dir"), FormatDir, encrypt)
+	assert.Error(t, err, "directories cannot be encrypted")
+}
+
+// writeKey writes a PEM encoded ed25519 key pair and returns the paths of
+// the private and public key files.
+func writeKey(t *testing.T) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	privPath := filepath.Join(dir, "sign.pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	der, err = x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	pubPath := filepath.Join(dir, "sign.pub")
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+	return privPath, pubPath
+}
+
+func TestLoadKeys(t *testing.T) {
+	privPath, pubPath := writeKey(t)
+	priv, err := LoadPrivateKey(privPath)
+	require.NoError(t, err)
+	pub, err := LoadPublicKey(pubPath)
+	require.NoError(t, err)
+	assert.True(t, pub.Equal(priv.Public()))
+
+	fromPriv, err := LoadPublicKey(privPath)
+	require.NoError(t, err)
+	assert.True(t, pub.Equal(fromPriv))
+
+	_, err = LoadPrivateKey(pubPath)
+	assert.Error(t, err)
+}
+
+func TestVerify_SignedFormats(t *testing.T) {
+	privPath, pubPath := writeKey(t)
+	priv, err := LoadPrivateKey(privPath)
+	require.NoError(t, err)
+	pub, err := LoadPublicKey(pubPath)
+	require.NoError(t, err)
+
+	for _, format := range []Format{FormatZip, FormatTarGz, FormatTarZst, FormatDir} {
+		t.Run(string(format), func(t *testing.T) {
+			name := WithExtension(filepath.Join(t.TempDir(), "out"), format)
+			arc, err := Create(name, format, Sign(priv))
+			require.NoError(t, err)
+			require.NoError(t, arc.Add("a.json", []byte(`{"a":1}`)))
+			require.NoError(t, arc.Add("dir/b.json", []byte(`[]`)))
+			arc.SetMeta("collector_version", "v1")
+			require.NoError(t, arc.Close())
+
+			r, err := Open(name)
+			require.NoError(t, err)
+			defer r.Close()
+
+			var seen []string
+			v, err := Verify(r, pub, func(name string, r io.Reader) error {
+				seen = append(seen, name)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.True(t, v.OK(), v.Problems)
+			assert.True(t, pub.Equal(v.Signer))
+			assert.Equal(t, "v1", v.Manifest.Meta["collector_version"])
+			assert.ElementsMatch(t, []string{"a.json", "dir/b.json"}, seen)
+
+			data, err := ReadFile(r, "a.json")
+			require.NoError(t, err)
+			assert.Equal(t, `{"a":1}`, string(data))
+			_, err = ReadFile(r, "missing.json")
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	privPath, pubPath := writeKey(t)
+	priv, err := LoadPrivateKey(privPath)
+	require.NoError(t, err)
+	pub, err := LoadPublicKey(pubPath)
+	require.NoError(t, err)
+
+	build := func(t *testing.T, opts ...Option) string {
+		dir := filepath.Join(t.TempDir(), "out")
+		arc, err := NewDirWriter(dir, opts...)
+		require.NoError(t, err)
+		require.NoError(t, arc.Add("a.json", []byte(`{"a":1}`)))
+		require.NoError(t, arc.Add("b.json", []byte(`{"b":2}`)))
+		require.NoError(t, arc.Close())
+		return dir
+	}
+	verify := func(t *testing.T, dir string, trusted ed25519.PublicKey) *Verification {
+		r, err := Open(dir)
+		require.NoError(t, err)
+		defer r.Close()
+		v, err := Verify(r, trusted, nil)
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("modified entry", func(t *testing.T) {
+		dir := build(t, Sign(priv))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"a":2}`), 0o644))
+		assert.Equal(t, []string{"a.json: content does not match the manifest hash"}, verify(t, dir, pub).Problems)
+	})
+	t.Run("missing and extra entries", func(t *testing.T) {
+		dir := build(t, Sign(priv))
+		require.NoError(t, os.Remove(filepath.Join(dir, "b.json")))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte(`{}`), 0o644))
+		assert.Equal(t, []string{
+			"b.json: listed in the manifest but missing",
+			"c.json: not listed in the manifest",
+		}, verify(t, dir, pub).Problems)
+	})
+	t.Run("re-hashed manifest", func(t *testing.T) {
+		dir := build(t, Sign(priv))
+		path := filepath.Join(dir, ManifestName)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, append(data, '\n'), 0o644))
+		assert.Equal(t, []string{"manifest signature is invalid"}, verify(t, dir, nil).Problems)
+	})
+	t.Run("untrusted key", func(t *testing.T) {
+		_, other, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		dir := build(t, Sign(other))
+		v := verify(t, dir, pub)
+		require.Len(t, v.Problems, 1)
+		assert.Contains(t, v.Problems[0], "untrusted key")
+		assert.True(t, verify(t, dir, nil).OK())
+	})
+	t.Run("unsigned", func(t *testing.T) {
+		dir := build(t)
+		assert.True(t, verify(t, dir, nil).OK())
+		assert.Equal(t, []string{"archive is not signed"}, verify(t, dir, pub).Problems)
+	})
+	t.Run("no manifest", func(t *testing.T) {
+		dir := build(t)
+		require.NoError(t, os.Remove(filepath.Join(dir, ManifestName)))
+		v := verify(t, dir, nil)
+		assert.Nil(t, v.Manifest)
+		assert.Equal(t, []string{ManifestName + " is missing"}, v.Problems)
+	})
+}
+
+func TestOpen_RejectsUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.zip.enc")
+	require.NoError(t, os.WriteFile(path, []byte("NDFCAES1..."), 0o644))
+	_, err := Open(path)
+	assert.ErrorContains(t, err, "decrypted first")
+}
+
+func TestPartName(t *testing.T) {
+	assert.Equal(t, "out.zip", PartName("out.zip", 1))
+	assert.Equal(t, "out.part2.zip", PartName("out.zip", 2))
+	assert.Equal(t, "dir/out.part10.ZIP.enc", PartName("dir/out.ZIP.enc", 10))
+	assert.Equal(t, "out.part3", PartName("out", 3))
+
+	assert.Equal(t, "out.zip", firstVolume("out.part2.zip"))
+	assert.Equal(t, filepath.Join("dir", "out.zip.enc"), firstVolume(filepath.Join("dir", "out.part12.zip.enc")))
+	assert.Equal(t, "out.zip", firstVolume("out.zip"))
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"100MB":  100_000_000,
+		"100 mb": 100_000_000,
+		"1.5G":   1_500_000_000,
+		"512MiB": 512 << 20,
+		"64k":    64_000,
+		"4096":   4096,
+		"10B":    10,
+	} {
+		got, err := ParseSize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "MB", "-1MB", "ten"} {
+		_, err := ParseSize(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestSplitWriter_StaleVolumes(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out.zip")
+	// Volumes of an earlier, larger archive of the same name.
+	for n := 2; n <= 4; n++ {
+		require.NoError(t, os.WriteFile(PartName(name, n), []byte("stale"), 0o600))
+	}
+	arc, err := Create(name, FormatZip, Split(64*1024))
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("a.json", []byte(`{}`)))
+	require.NoError(t, arc.Close())
+
+	assert.Equal(t, []string{name}, Volumes(name))
+	for n := 2; n <= 4; n++ {
+		assert.NoFileExists(t, PartName(name, n))
+	}
+}
+
+func TestSplitWriter(t *testing.T) {
+	const limit = 64 * 1024
+	name := filepath.Join(t.TempDir(), "out.zip")
+	arc, err := Create(name, FormatZip, Split(limit))
+	require.NoError(t, err)
+
+	// Random data does not compress, so each entry takes ~20 KB.
+	want := map[string]string{}
+	rnd := make([]byte, 20*1024)
+	for i := range 8 {
+		_, err := rand.Read(rnd)
+		require.NoError(t, err)
+		content := string(rnd)
+		name := fmt.Sprintf("entry%d.json", i)
+		want[name] = content
+		require.NoError(t, arc.Add(name, []byte(content)))
+	}
+	arc.SetMeta("collector_version", "v1")
+	require.NoError(t, arc.Close())
+
+	volumes := Volumes(name)
+	require.Greater(t, len(volumes), 2)
+	for _, v := range volumes {
+		info, err := os.Stat(v)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(limit), v)
+	}
+
+	// Any volume opens the whole set.
+	r, err := Open(volumes[1])
+	require.NoError(t, err)
+	defer r.Close()
+	got := map[string]string{}
+	v, err := Verify(r, nil, func(name string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		got[name] = string(data)
+		return err
+	})
+	require.NoError(t, err)
+	assert.True(t, v.OK(), v.Problems)
+	assert.Equal(t, want, got)
+
+	require.Len(t, v.Manifest.Parts, len(volumes))
+	assert.Equal(t, "out.part2.zip", v.Manifest.Parts[1])
+	for _, e := range v.Manifest.Entries {
+		zr, err := zip.OpenReader(volumes[e.Part-1])
+		require.NoError(t, err)
+		_, err = zr.Open(e.Name)
+		assert.NoError(t, err, "%s is in volume %d", e.Name, e.Part)
+		zr.Close()
+	}
+	zr, err := zip.OpenReader(volumes[0])
+	require.NoError(t, err)
+	defer zr.Close()
+	_, err = zr.Open(ManifestName)
+	assert.NoError(t, err, "manifest is in the first volume")
+}
+
+func TestSplitWriter_MissingVolume(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out.zip")
+	arc, err := Create(name, FormatZip, Split(8*1024))
+	require.NoError(t, err)
+	rnd := make([]byte, 4*1024)
+	for i := range 4 {
+		_, err := rand.Read(rnd)
+		require.NoError(t, err)
+		require.NoError(t, arc.Add(fmt.Sprintf("entry%d", i), rnd))
+	}
+	require.NoError(t, arc.Close())
+	require.NoError(t, os.Remove(PartName(name, 2)))
+	_, err = Open(name)
+	assert.ErrorContains(t, err, "volume 2")
+}
+
+func TestSplitWriter_OversizeEntry(t *testing.T) {
+	var buf bytes.Buffer
+	name := filepath.Join(t.TempDir(), "out.zip")
+	arc, err := Create(name, FormatZip, Split(8*1024), Logger(log.Tee(&buf)))
+	require.NoError(t, err)
+	assert.Error(t, arc.Add("../escape.json", []byte("{}")))
+	rnd := make([]byte, 16*1024)
+	_, err = rand.Read(rnd)
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("small.json", []byte("{}")))
+	require.NoError(t, arc.Add("big.json", rnd))
+	require.NoError(t, arc.Close())
+	assert.Contains(t, buf.String(), "big.json is 16")
+	assert.Contains(t, buf.String(), "more than the volume size of 8192 bytes")
+
+	r, err := Open(name)
+	require.NoError(t, err)
+	defer r.Close()
+	v, err := Verify(r, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, v.OK(), v.Problems)
+}
+
+func TestOpen_RejectsVolumePaths(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "out.zip")
+	f, err := os.Create(name)
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	w, err := zw.Create(ManifestName)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(w).Encode(Manifest{Parts: []string{"out.zip", "../other/out.part2.zip"}}))
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	_, err = Open(name)
+	assert.ErrorContains(t, err, `invalid volume name "../other/out.part2.zip"`)
+}
+
+func TestCreate_SplitRequiresZip(t *testing.T) {
+	_, err := Create(filepath.Join(t.TempDir(), "out.tar.gz"), FormatTarGz, Split(1<<20))
+	assert.Error(t, err)
+}
+
+func TestSpool_Encrypted(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	plain := bytes.Repeat([]byte(`{"secret":"value"}`), 1000)
+	for _, encrypt := range []bool{false, true} {
+		s, err := newSpool("spool-*", encrypt)
+		require.NoError(t, err)
+		// Uneven writes exercise counter blocks split across calls.
+		for rest := plain; len(rest) > 0; {
+			n := min(len(rest), 7)
+			_, err := s.Write(rest[:n])
+			require.NoError(t, err)
+			rest = rest[n:]
+		}
+		onDisk, err := os.ReadFile(s.f.Name())
+		require.NoError(t, err)
+		assert.Equal(t, !encrypt, bytes.Equal(plain, onDisk), "encrypt %v", encrypt)
+
+		got, err := io.ReadAll(s.Reader())
+		require.NoError(t, err)
+		assert.Equal(t, plain, got)
+		part := make([]byte, 100)
+		_, err = s.ReadAt(part, 37)
+		require.NoError(t, err)
+		assert.Equal(t, plain[37:137], part)
+
+		require.NoError(t, s.Close())
+		assert.NoFileExists(t, s.f.Name())
+	}
+}
+
+func TestFileWriter_Reproducible(t *testing.T) {
+	dir := t.TempDir()
+	entries := make([]map[string][]byte, 2)
+	for i := range entries {
+		name := filepath.Join(dir, fmt.Sprintf("out%d.zip", i))
+		arc, err := NewWriter(name)
+		require.NoError(t, err)
+		require.NoError(t, arc.Add("a.json", []byte(`{"a":1}`)))
+		require.NoError(t, arc.AddReader("b.json", strings.NewReader(`[]`)))
+		require.NoError(t, arc.Close())
+
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		entries[i] = map[string][]byte{}
+		for _, f := range zr.File {
+			if f.Name == ManifestName {
+				continue
+			}
+			assert.True(t, f.Modified.Equal(entryTime), f.Name)
+			// Everything from the end of the local header's name to the
+			// end of the compressed data.
+			off, err := f.DataOffset()
+			require.NoError(t, err)
+			entries[i][f.Name] = data[off-int64(len(f.Extra)) : off+int64(f.CompressedSize64)]
+		}
+	}
+	assert.Equal(t, entries[0], entries[1])
+}
+
+func TestTarWriter_Closed(t *testing.T) {
+	arc, err := NewTarWriter(filepath.Join(t.TempDir(), "out.tar.gz"), FormatTarGz)
+	require.NoError(t, err)
+	require.NoError(t, arc.Close())
+	assert.ErrorIs(t, arc.Add("late.json", []byte(`{}`)), ErrClosed)
+	assert.ErrorIs(t, arc.Close(), ErrClosed)
+}
+
+func TestDirWriter_RefusesNonEmpty(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stale.json"), []byte(`{}`), 0o644))
+	_, err := NewDirWriter(dir)
+	assert.ErrorContains(t, err, "not empty")
+
+	arc, err := NewDirWriter(filepath.Join(dir, "new"))
+	require.NoError(t, err)
+	require.NoError(t, arc.Close())
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+
+	"ndfc-collector/pkg/log"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Zip compression methods supported by the archive writer.
+const (
+	Store   uint16 = zip.Store
+	Deflate uint16 = zip.Deflate
+	// Zstd is the zip method ID for Zstandard (as used by WinZip and 7-Zip).
+	Zstd uint16 = zstd.ZipMethodWinZip
+)
+
+// Level is a compression level, independent of the compression method.
+type Level string
+
+// Compression levels. LevelStore disables compression altogether.
+const (
+	LevelStore   Level = "store"
+	LevelFast    Level = "fast"
+	LevelDefault Level = "default"
+	LevelBest    Level = "best"
+)
+
+// Option configures an archive writer.
+type Option func(*options)
+
+// options holds the settings shared by all archive formats.
+type options struct {
+	method      uint16
+	level       Level
+	compressors map[uint16]zip.Compressor
+	encrypt     func(io.Writer) (io.WriteCloser, error)
+	sign        ed25519.PrivateKey
+	split       int64
+	logger      *log.Logger
+}
+
+// newOptions applies opts on top of the defaults.
+func newOptions(opts []Option) options {
+	o := options{method: Deflate, level: LevelDefault}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.level == LevelStore {
+		o.method = Store
+	}
+	return o
+}
+
+// Compression sets the zip compression method used for new entries.
+// The default is Deflate. Tar formats use the compression implied by the
+// format and ignore this option.
+func Compression(method uint16) Option {
+	return func(o *options) {
+		o.method = method
+	}
+}
+
+// CompressionLevel sets the compression level used for new entries.
+// The default is LevelDefault.
+func CompressionLevel(level Level) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// RegisterCompressor registers a custom zip compressor for method,
+// overriding the built-in Deflate and Zstd compressors.
+func RegisterCompressor(method uint16, comp zip.Compressor) Option {
+	return func(o *options) {
+		if o.compressors == nil {
+			o.compressors = map[uint16]zip.Compressor{}
+		}
+		o.compressors[method] = comp
+	}
+}
+
+// RegisterDecompressors registers decompressors for every method the
+// writer can produce, so that zr can read any archive written by this package.
+func RegisterDecompressors(zr *zip.Reader) {
+	zr.RegisterDecompressor(Zstd, zstd.ZipDecompressor())
+}
+
+// ParseCompression converts method and level names, as used in the config
+// file and CLI, into writer options.
+func ParseCompression(method, level string) ([]Option, error) {
+	var mods []Option
+	switch method {
+	case "", "deflate":
+		mods = append(mods, Compression(Deflate))
+	case "zstd":
+		mods = append(mods, Compression(Zstd))
+	case "store":
+		mods = append(mods, Compression(Store))
+	default:
+		return nil, fmt.Errorf("unknown compression method %q", method)
+	}
+	switch Level(level) {
+	case "":
+	case LevelStore, LevelFast, LevelDefault, LevelBest:
+		mods = append(mods, CompressionLevel(Level(level)))
+	default:
+		return nil, fmt.Errorf("unknown compression level %q", level)
+	}
+	return mods, nil
+}
+
+// flateLevel returns the compress/flate level (also used by gzip).
+func (o options) flateLevel() int {
+	switch o.level {
+	case LevelStore:
+		return flate.NoCompression
+	case LevelFast:
+		return flate.BestSpeed
+	case LevelBest:
+		return flate.BestCompression
+	}
+	return flate.DefaultCompression
+}
+
+// zstdLevel returns the Zstandard encoder level.
+func (o options) zstdLevel() zstd.EncoderLevel {
+	switch o.level {
+	case LevelStore, LevelFast:
+		return zstd.SpeedFastest
+	case LevelBest:
+		return zstd.SpeedBestCompression
+	}
+	return zstd.SpeedDefault
+}
+
+// registerCompressors configures zw for the chosen method and level.
+func (o options) registerCompressors(zw *zip.Writer) {
+	switch o.method {
+	case Deflate:
+		level := o.flateLevel()
+		zw.RegisterCompressor(Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		})
+	case Zstd:
+		zw.RegisterCompressor(Zstd, zstd.ZipCompressor(zstd.WithEncoderLevel(o.zstdLevel())))
+	}
+	for method, comp := range o.compressors {
+		zw.RegisterCompressor(method, comp)
+	}
+}
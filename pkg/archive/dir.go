@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DirWriter writes archive entries as plain files in a directory, for
+// engineers who want to browse a collection without unpacking it.
+type DirWriter struct {
+	mu       sync.Mutex
+	dir      string
+	manifest *Manifest
+	sign     ed25519.PrivateKey
+}
+
+// NewDirWriter creates a directory writer, creating dir if needed. An
+// existing directory must be empty, so the collection is never mixed with
+// files the manifest does not list. Compression and encryption options do
+// not apply to directories.
+func NewDirWriter(dir string, opts ...Option) (*DirWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("%s is not empty", dir)
+	}
+	o := newOptions(opts)
+	return &DirWriter{dir: dir, manifest: newManifest(), sign: o.sign}, nil
+}
+
+// Add writes content to a file in the directory.
+func (a *DirWriter) Add(name string, content []byte) error {
+	return a.AddReader(name, bytes.NewReader(content))
+}
+
+// AddReader streams the content of r into a file in the directory,
+// recording its size and SHA-256 hash in the manifest.
+func (a *DirWriter) AddReader(name string, r io.Reader) error {
+	entry, err := a.write(name, r)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.manifest.Entries = append(a.manifest.Entries, entry)
+	return nil
+}
+
+// write creates a single file. Distinct entries do not share any state, so
+// no lock is needed.
+func (a *DirWriter) write(name string, r io.Reader) (Entry, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return Entry{}, err
+	}
+	path := filepath.Join(a.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Entry{}, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry, err := copyEntry(name, f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return entry, err
+}
+
+// SetMeta records a collection-level value in the manifest.
+func (a *DirWriter) SetMeta(key string, value any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.manifest.setMeta(key, value)
+}
+
+// Close writes the manifest, signing it if requested.
+func (a *DirWriter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	files, err := a.manifest.trailer(a.sign)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if _, err := a.write(f.name, bytes.NewReader(f.data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"io"
+)
+
+// Encrypt wraps the archive's output file with the encrypting WriteCloser
+// returned by wrap (e.g. crypt.NewWriter), so that the archive is never
+// written in plaintext. Entries spooled to temporary files before being
+// added are encrypted with an ephemeral key. Encryption is not supported
+// for FormatDir.
+func Encrypt(wrap func(io.Writer) (io.WriteCloser, error)) Option {
+	return func(o *options) {
+		o.encrypt = wrap
+	}
+}
+
+// encryptedFile closes the encryption stream, flushing its final block,
+// before closing the file underneath it.
+type encryptedFile struct {
+	io.WriteCloser
+	file io.Closer
+}
+
+func (e encryptedFile) Close() error {
+	if err := e.WriteCloser.Close(); err != nil {
+		e.file.Close()
+		return err
+	}
+	return e.file.Close()
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ManifestName is the archive entry listing every other entry.
+const ManifestName = "manifest.json"
+
+// Entry describes a single file stored in an archive.
+type Entry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	Part   int    `json:"part,omitempty"` // volume holding the entry in a split archive
+}
+
+// Manifest describes the contents of an archive.
+// It is written as the last entry when the archive is closed, followed by
+// its signature when the archive is signed.
+type Manifest struct {
+	Created time.Time      `json:"created"`
+	Meta    map[string]any `json:"meta,omitempty"`
+	Parts   []string       `json:"parts,omitempty"` // volume file names of a split archive, in order
+	Entries []Entry        `json:"entries"`
+}
+
+// entryTime is the modification time of every archive entry. Entries of
+// two collections with the same content are then byte-identical; the time
+// of the collection is only recorded as Created in the manifest. It is the
+// earliest time zip headers can hold.
+var entryTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newManifest returns an empty manifest stamped with the current time.
+func newManifest() *Manifest {
+	return &Manifest{Created: time.Now().UTC(), Entries: []Entry{}}
+}
+
+// setMeta records a collection-level value, e.g. the collector version.
+func (m *Manifest) setMeta(key string, value any) {
+	if m.Meta == nil {
+		m.Meta = map[string]any{}
+	}
+	m.Meta[key] = value
+}
+
+// marshal renders the manifest as indented JSON.
+func (m *Manifest) marshal() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// trailerFile is an entry written by Close that is not itself listed in
+// the manifest.
+type trailerFile struct {
+	name string
+	data []byte
+}
+
+// trailer returns the manifest and, when key is set, its signature.
+func (m *Manifest) trailer(key ed25519.PrivateKey) ([]trailerFile, error) {
+	data, err := m.marshal()
+	if err != nil {
+		return nil, err
+	}
+	files := []trailerFile{{ManifestName, data}}
+	if key != nil {
+		sig, err := sign(key, data)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, trailerFile{SignatureName, sig})
+	}
+	return files, nil
+}
+
+// copyEntry copies r to w, returning the manifest entry describing the
+// content written.
+func copyEntry(name string, w io.Writer, r io.Reader) (Entry, error) {
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Name: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
@@ -0,0 +1,309 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Reader reads the entries of an archive written by Create.
+type Reader interface {
+	// Walk calls fn for every entry in archive order. Tarballs can only
+	// be read front to back, so entries are not randomly accessible.
+	Walk(fn func(name string, r io.Reader) error) error
+	Close() error
+}
+
+// errStop ends a Walk early without reporting an error.
+var errStop = errors.New("stop")
+
+// File magic numbers used to detect the archive format.
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Open opens an archive for reading. The format is detected from the
+// content rather than the name: a directory, zip, tar.gz or tar.zst.
+// Split zip archives are read as a whole, given the name of any volume.
+func Open(name string) (Reader, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return dirReader{dir: name}, nil
+	}
+	if first := firstVolume(name); first != name {
+		return Open(first)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 4)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, zipMagic):
+		z, err := newZipReader(f, info.Size())
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return openParts(name, z)
+	case bytes.HasPrefix(head, gzipMagic):
+		return &tarReader{f: f, format: FormatTarGz}, nil
+	case bytes.HasPrefix(head, zstdMagic):
+		return &tarReader{f: f, format: FormatTarZst}, nil
+	}
+	f.Close()
+	return nil, fmt.Errorf("%s is not a zip, tar.gz or tar.zst archive; encrypted archives must be decrypted first", name)
+}
+
+// ReadFile returns the content of the named entry.
+func ReadFile(r Reader, name string) ([]byte, error) {
+	var data []byte
+	found := false
+	err := r.Walk(func(n string, rd io.Reader) error {
+		if n != name {
+			return nil
+		}
+		found = true
+		var err error
+		if data, err = io.ReadAll(rd); err != nil {
+			return err
+		}
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return data, nil
+}
+
+type zipReader struct {
+	f  *os.File
+	zr *zip.Reader
+}
+
+func newZipReader(f *os.File, size int64) (zipReader, error) {
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return zipReader{}, err
+	}
+	RegisterDecompressors(zr)
+	return zipReader{f: f, zr: zr}, nil
+}
+
+// openZip opens a zip file that is not the first volume of a split archive.
+func openZip(name string) (zipReader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return zipReader{}, err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		var z zipReader
+		if z, err = newZipReader(f, info.Size()); err == nil {
+			return z, nil
+		}
+	}
+	f.Close()
+	return zipReader{}, err
+}
+
+// openParts opens the remaining volumes listed in the manifest of first,
+// returning a reader over all of them. A single volume is returned as is.
+func openParts(name string, first zipReader) (Reader, error) {
+	var m Manifest
+	for _, zf := range first.zr.File {
+		if zf.Name != ManifestName {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			break
+		}
+		err = json.NewDecoder(rc).Decode(&m)
+		rc.Close()
+		if err != nil {
+			// Leave reporting a corrupt manifest to Verify.
+			return first, nil
+		}
+	}
+	if len(m.Parts) <= 1 {
+		return first, nil
+	}
+	parts := multiReader{first}
+	for i, part := range m.Parts[1:] {
+		// Volumes sit next to the first; a path could point anywhere.
+		if filepath.Base(part) != part || part == ".." {
+			parts.Close()
+			return nil, fmt.Errorf("volume %d of %s: invalid volume name %q", i+2, name, part)
+		}
+		z, err := openZip(filepath.Join(filepath.Dir(name), part))
+		if err != nil {
+			parts.Close()
+			return nil, fmt.Errorf("volume %d of %s: %w", i+2, name, err)
+		}
+		parts = append(parts, z)
+	}
+	return parts, nil
+}
+
+// multiReader reads the volumes of a split archive in order.
+type multiReader []Reader
+
+func (m multiReader) Walk(fn func(string, io.Reader) error) error {
+	for _, r := range m {
+		if err := r.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiReader) Close() error {
+	var first error
+	for _, r := range m {
+		if err := r.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (z zipReader) Walk(fn func(string, io.Reader) error) error {
+	for _, zf := range z.zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", zf.Name, err)
+		}
+		err = fn(zf.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (z zipReader) Close() error {
+	return z.f.Close()
+}
+
+type tarReader struct {
+	f      *os.File
+	format Format
+}
+
+func (t *tarReader) Walk(fn func(string, io.Reader) error) error {
+	if _, err := t.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	var codec io.Reader
+	switch t.format {
+	case FormatTarZst:
+		zr, err := zstd.NewReader(bufio.NewReader(t.f))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		codec = zr
+	default:
+		gr, err := gzip.NewReader(bufio.NewReader(t.f))
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		codec = gr
+	}
+	tr := tar.NewReader(codec)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *tarReader) Close() error {
+	return t.f.Close()
+}
+
+type dirReader struct {
+	dir string
+}
+
+// Walk visits files in lexical order, which unlike the other formats is
+// not necessarily the order they were written in.
+func (d dirReader) Walk(fn func(string, io.Reader) error) error {
+	return filepath.WalkDir(d.dir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil || !e.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(d.dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return fn(filepath.ToSlash(rel), f)
+	})
+}
+
+func (d dirReader) Close() error {
+	return nil
+}
@@ -0,0 +1,384 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"ndfc-collector/pkg/log"
+)
+
+// Size estimates used to keep volumes under their limit. Local headers,
+// data descriptors and central directory records are a fixed size plus
+// the entry name; the slack covers extra fields.
+const (
+	localHeaderSize = 30 + 24 + 64
+	centralDirSize  = 46 + 64
+	endOfDirSize    = 22 + 56 + 20
+)
+
+// Split writes a zip archive as a set of volumes no larger than limit bytes.
+// Only FormatZip can be split.
+func Split(limit int64) Option {
+	return func(o *options) {
+		o.split = limit
+	}
+}
+
+// Logger sets the logger warnings about the archive are written to, such
+// as a split archive entry too large for its volume. The default is the
+// global logger.
+func Logger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = &logger
+	}
+}
+
+// log returns the logger set with Logger.
+func (o *options) log() log.Logger {
+	if o.logger != nil {
+		return *o.logger
+	}
+	return log.New()
+}
+
+// SplitWriter writes a zip archive as a set of volumes, each no larger than
+// a size limit, for upload portals that cap file sizes. The first volume
+// keeps the archive name and later ones are named by PartName, e.g.
+// ndfc-collection-data.part2.zip. Entries are never split across volumes.
+// The manifest, which records the volume holding each entry, is written to
+// the first volume; a share of that volume is reserved for it.
+type SplitWriter struct {
+	mu       sync.Mutex
+	name     string
+	budget   int64 // usable bytes per volume
+	reserve  int64 // bytes kept free in the first volume for the manifest
+	limit    int64
+	opts     options
+	manifest *Manifest
+	volumes  []*volume
+}
+
+// volume is a single zip file of a split archive.
+type volume struct {
+	name    string
+	out     io.WriteCloser
+	count   *countingWriter
+	zw      *zip.Writer
+	dirSize int64 // estimated central directory size
+	entries int
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewSplitWriter creates a split zip archive whose first volume is name.
+func NewSplitWriter(name string, limit int64, opts ...Option) (*SplitWriter, error) {
+	o := newOptions(opts)
+	budget := limit
+	if o.encrypt != nil {
+		// Encryption adds a header and a 16 byte tag per 64 KiB chunk.
+		budget -= limit/1000 + 4096
+	}
+	if budget <= 0 {
+		return nil, fmt.Errorf("volume size %d is too small", limit)
+	}
+	a := &SplitWriter{
+		name:     name,
+		budget:   budget,
+		reserve:  budget / 20,
+		limit:    limit,
+		opts:     o,
+		manifest: newManifest(),
+	}
+	if err := a.openVolume(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// PartName returns the file name of volume n (counting from 1) of the split
+// archive name. The first volume is name itself.
+//
+//	PartName("ndfc-collection-data.zip", 2) // ndfc-collection-data.part2.zip
+func PartName(name string, n int) string {
+	if n == 1 {
+		return name
+	}
+	part := ".part" + strconv.Itoa(n)
+	if i := strings.LastIndex(strings.ToLower(name), ".zip"); i >= 0 {
+		return name[:i] + part + name[i:]
+	}
+	return name + part
+}
+
+// partRe matches the volume number in the name of a later volume.
+var partRe = regexp.MustCompile(`(?i)\.part(\d+)(\.zip)`)
+
+// firstVolume returns the name of the first volume of the split archive
+// that name belongs to.
+func firstVolume(name string) string {
+	base := filepath.Base(name)
+	loc := partRe.FindStringSubmatchIndex(base)
+	if loc == nil {
+		return name
+	}
+	return filepath.Join(filepath.Dir(name), base[:loc[0]]+base[loc[4]:])
+}
+
+// openVolume creates the next volume. The caller holds a.mu.
+func (a *SplitWriter) openVolume() error {
+	name := PartName(a.name, len(a.volumes)+1)
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	var out io.WriteCloser = f
+	if a.opts.encrypt != nil {
+		enc, err := a.opts.encrypt(f)
+		if err != nil {
+			f.Close()
+			os.Remove(name)
+			return err
+		}
+		out = encryptedFile{WriteCloser: enc, file: f}
+	}
+	count := &countingWriter{w: out}
+	zw := zip.NewWriter(count)
+	a.opts.registerCompressors(zw)
+	a.volumes = append(a.volumes, &volume{name: name, out: out, count: count, zw: zw})
+	return nil
+}
+
+// Add adds a file and content to the archive.
+func (a *SplitWriter) Add(name string, content []byte) error {
+	return a.AddReader(name, bytes.NewReader(content))
+}
+
+// AddReader compresses the content of r into a temporary single-entry zip,
+// encrypted if the volumes are, to learn its exact size, then copies the compressed entry into the
+// current volume, starting a new volume if it does not fit.
+func (a *SplitWriter) AddReader(name string, r io.Reader) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	spool, err := newSpool("ndfc-collector-*.zip", a.opts.encrypt != nil)
+	if err != nil {
+		return err
+	}
+	defer spool.Close()
+
+	zw := zip.NewWriter(spool)
+	a.opts.registerCompressors(zw)
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   a.opts.method,
+		Modified: entryTime,
+	})
+	if err != nil {
+		return err
+	}
+	entry, err := copyEntry(name, fw, r)
+	if err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(spool, spool.size)
+	if err != nil {
+		return err
+	}
+	zf := zr.File[0]
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	v := a.volumes[len(a.volumes)-1]
+	need := localHeaderSize + centralDirSize + 2*int64(len(name)) + int64(zf.CompressedSize64)
+	if need+endOfDirSize > a.budget {
+		logger := a.opts.log()
+		logger.Warn().Str("entry", name).Msgf("%s is %d bytes compressed, more than the volume size of %d bytes; its volume will exceed the limit.",
+			name, zf.CompressedSize64, a.limit)
+	}
+	if v.entries > 0 && !a.fits(v, need) {
+		if err := a.rollOver(); err != nil {
+			return err
+		}
+		v = a.volumes[len(a.volumes)-1]
+	}
+	if err := v.zw.Copy(zf); err != nil {
+		return err
+	}
+	if err := v.zw.Flush(); err != nil {
+		return err
+	}
+	v.entries++
+	v.dirSize += centralDirSize + int64(len(name))
+	entry.Part = len(a.volumes)
+	a.manifest.Entries = append(a.manifest.Entries, entry)
+	return nil
+}
+
+// fits reports whether need more bytes fit in v.
+func (a *SplitWriter) fits(v *volume, need int64) bool {
+	used := v.count.n + v.dirSize + endOfDirSize
+	if v == a.volumes[0] {
+		used += a.reserve
+	}
+	return used+need <= a.budget
+}
+
+// rollOver finishes all but the first volume, which stays open for the
+// manifest, and starts a new one. The caller holds a.mu.
+func (a *SplitWriter) rollOver() error {
+	if last := a.volumes[len(a.volumes)-1]; len(a.volumes) > 1 {
+		if err := last.close(); err != nil {
+			return err
+		}
+	}
+	return a.openVolume()
+}
+
+func (v *volume) close() error {
+	if err := v.zw.Close(); err != nil {
+		v.out.Close()
+		return err
+	}
+	return v.out.Close()
+}
+
+// SetMeta records a collection-level value in the manifest.
+func (a *SplitWriter) SetMeta(key string, value any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.manifest.setMeta(key, value)
+}
+
+// Close finishes the last volume, then writes the manifest, listing every
+// volume, to the first. Higher-numbered volumes left over from an earlier
+// archive of the same name are removed, so that they are not taken for
+// part of this one.
+func (a *SplitWriter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.volumes) > 1 {
+		if err := a.volumes[len(a.volumes)-1].close(); err != nil {
+			return err
+		}
+	}
+	for _, v := range a.volumes {
+		a.manifest.Parts = append(a.manifest.Parts, filepath.Base(v.name))
+	}
+
+	first := a.volumes[0]
+	files, err := a.manifest.trailer(a.opts.sign)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		w, err := first.zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   a.opts.method,
+			Modified: entryTime,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := first.close(); err != nil {
+		return err
+	}
+	for n := len(a.volumes) + 1; ; n++ {
+		if err := os.Remove(PartName(a.name, n)); err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			break
+		}
+	}
+	if info, err := os.Stat(first.name); err == nil && info.Size() > a.limit {
+		return fmt.Errorf("%s is %d bytes, over the volume size of %d bytes, as its manifest lists %d entries",
+			first.name, info.Size(), a.limit, len(a.manifest.Entries))
+	}
+	return nil
+}
+
+// sizeUnits maps size suffixes to multipliers, longest first.
+var sizeUnits = []struct {
+	suffix string
+	mult   float64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+	{"K", 1e3}, {"M", 1e6}, {"G", 1e9},
+	{"B", 1},
+}
+
+// ParseSize parses a volume size such as "100MB", "1.5GB" or "512MiB".
+// KB, MB and GB are powers of 1000, so "100MB" stays under a portal's
+// 100 MB limit however it counts; KiB, MiB and GiB are powers of 1024.
+// A bare number is a size in bytes.
+func ParseSize(s string) (int64, error) {
+	num := strings.ToUpper(strings.TrimSpace(s))
+	mult := 1.0
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, mult = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * mult), nil
+}
+
+// Volumes returns the names of the volumes of the split archive name that
+// exist on disk, starting with name itself.
+func Volumes(name string) []string {
+	var names []string
+	for n := 1; ; n++ {
+		part := PartName(name, n)
+		if _, err := os.Stat(part); err != nil {
+			return names
+		}
+		names = append(names, part)
+	}
+}
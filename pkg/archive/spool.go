@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// spool is a temporary file that entries of unknown size are written to
+// before being added. When the archive is encrypted, the spool is encrypted
+// with AES-CTR under a random key that only lives in memory, so no
+// plaintext touches the disk; the key is never reused across spools.
+type spool struct {
+	f     *os.File
+	block cipher.Block // nil when the spool is not encrypted
+	size  int64
+}
+
+// newSpool creates a spool in os.TempDir named after pattern.
+func newSpool(pattern string, encrypt bool) (*spool, error) {
+	s := &spool{}
+	if encrypt {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		s.block = block
+	}
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.f = f
+	return s, nil
+}
+
+// Write appends p to the spool.
+func (s *spool) Write(p []byte) (int, error) {
+	if s.block != nil {
+		enc := make([]byte, len(p))
+		s.xor(enc, p, s.size)
+		p = enc
+	}
+	n, err := s.f.WriteAt(p, s.size)
+	s.size += int64(n)
+	return n, err
+}
+
+// ReadAt reads len(p) bytes written to the spool starting at off.
+func (s *spool) ReadAt(p []byte, off int64) (int, error) {
+	n, err := s.f.ReadAt(p, off)
+	if s.block != nil {
+		s.xor(p[:n], p[:n], off)
+	}
+	return n, err
+}
+
+// Reader returns a reader for everything written to the spool.
+func (s *spool) Reader() io.Reader {
+	return io.NewSectionReader(s, 0, s.size)
+}
+
+// Close closes and removes the spool file.
+func (s *spool) Close() error {
+	err := s.f.Close()
+	if rerr := os.Remove(s.f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// xor applies the key stream at offset off of the spool to src.
+func (s *spool) xor(dst, src []byte, off int64) {
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(iv[8:], uint64(off/aes.BlockSize))
+	stream := cipher.NewCTR(s.block, iv)
+	if skip := off % aes.BlockSize; skip > 0 {
+		pad := make([]byte, skip)
+		stream.XORKeyStream(pad, pad)
+	}
+	stream.XORKeyStream(dst, src)
+}
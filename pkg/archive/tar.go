@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// TarWriter writes a compressed tarball (tar+gzip or tar+zstd).
+type TarWriter struct {
+	mu       sync.Mutex
+	closed   bool
+	out      io.WriteCloser // underlying file
+	codec    io.WriteCloser // gzip or zstd stream
+	tw       *tar.Writer
+	manifest *Manifest
+	sign     ed25519.PrivateKey
+	encrypt  bool // encrypt spooled entries
+}
+
+// NewTarWriter creates a tarball writer for name, compressed according to
+// format, which must be FormatTarGz or FormatTarZst.
+func NewTarWriter(name string, format Format, opts ...Option) (*TarWriter, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	a, err := newTarWriter(f, format, opts...)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// newTarWriter creates a tarball writer on top of out.
+func newTarWriter(out io.WriteCloser, format Format, opts ...Option) (*TarWriter, error) {
+	o := newOptions(opts)
+	var codec io.WriteCloser
+	var err error
+	switch format {
+	case FormatTarZst:
+		codec, err = zstd.NewWriter(out, zstd.WithEncoderLevel(o.zstdLevel()))
+	default:
+		codec, err = gzip.NewWriterLevel(out, o.flateLevel())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &TarWriter{
+		out:      out,
+		codec:    codec,
+		tw:       tar.NewWriter(codec),
+		manifest: newManifest(),
+		sign:     o.sign,
+		encrypt:  o.encrypt != nil,
+	}, nil
+}
+
+// Add adds a file and content to the tarball.
+func (a *TarWriter) Add(name string, content []byte) error {
+	return a.AddReader(name, bytes.NewReader(content))
+}
+
+// AddReader streams the content of r into a new file in the tarball,
+// recording its size and SHA-256 hash in the manifest.
+func (a *TarWriter) AddReader(name string, r io.Reader) error {
+	// Tar headers carry the entry size, so it must be known up front.
+	size, r, cleanup, err := sized(r, a.encrypt)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry, err := a.add(name, size, r)
+	if err != nil {
+		return err
+	}
+	a.manifest.Entries = append(a.manifest.Entries, entry)
+	return nil
+}
+
+// add writes a single tar entry. The caller holds a.mu.
+func (a *TarWriter) add(name string, size int64, r io.Reader) (Entry, error) {
+	if a.closed {
+		return Entry{}, ErrClosed
+	}
+	name, err := cleanName(name)
+	if err != nil {
+		return Entry{}, err
+	}
+	err = a.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0o644,
+		ModTime:  entryTime,
+	})
+	if err != nil {
+		return Entry{}, err
+	}
+	return copyEntry(name, a.tw, r)
+}
+
+// SetMeta records a collection-level value in the manifest.
+func (a *TarWriter) SetMeta(key string, value any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.manifest.setMeta(key, value)
+}
+
+// Close writes the manifest, signing it if requested, and closes the
+// tarball and file. Closing it again returns ErrClosed.
+func (a *TarWriter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return ErrClosed
+	}
+	files, err := a.manifest.trailer(a.sign)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if _, err := a.add(f.name, int64(len(f.data)), bytes.NewReader(f.data)); err != nil {
+			return err
+		}
+	}
+	a.closed = true
+	if err := a.tw.Close(); err != nil {
+		a.out.Close()
+		return err
+	}
+	if err := a.codec.Close(); err != nil {
+		a.out.Close()
+		return err
+	}
+	return a.out.Close()
+}
+
+// sized returns the number of bytes remaining in r along with a reader for
+// them. Files and in-memory readers are measured directly; anything else is
+// spooled to a temporary file, encrypted if encrypt is set, which cleanup
+// removes.
+func sized(r io.Reader, encrypt bool) (int64, io.Reader, func(), error) {
+	noop := func() {}
+	switch v := r.(type) {
+	case *bytes.Reader:
+		return int64(v.Len()), v, noop, nil
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil {
+			return 0, nil, noop, err
+		}
+		pos, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, nil, noop, err
+		}
+		return info.Size() - pos, v, noop, nil
+	}
+
+	spool, err := newSpool("ndfc-collector-*.tmp", encrypt)
+	if err != nil {
+		return 0, nil, noop, err
+	}
+	cleanup := func() { spool.Close() }
+	n, err := io.Copy(spool, r)
+	if err != nil {
+		cleanup()
+		return 0, nil, noop, err
+	}
+	return n, spool.Reader(), cleanup, nil
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Verification is the result of checking an archive against its manifest.
+type Verification struct {
+	Manifest *Manifest         // nil when the archive has no readable manifest
+	Signer   ed25519.PublicKey // key the manifest is signed with; nil when unsigned
+	Problems []string          // integrity failures; empty when the archive is intact
+}
+
+// OK reports whether the archive passed every check.
+func (v *Verification) OK() bool {
+	return len(v.Problems) == 0
+}
+
+func (v *Verification) problem(format string, args ...any) {
+	v.Problems = append(v.Problems, fmt.Sprintf(format, args...))
+}
+
+// hashCounter hashes and counts the bytes written to it.
+type hashCounter struct {
+	h hash.Hash
+	n int64
+}
+
+func (c *hashCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return c.h.Write(p)
+}
+
+// Verify checks every entry of r against the sizes and SHA-256 hashes in
+// its manifest, and the manifest against its signature. When trusted is
+// set the archive must be signed with that key. fn, if not nil, is called
+// for every other entry as it is read so callers can inspect content in
+// the same pass. An error is returned only if the archive cannot be read.
+func Verify(r Reader, trusted ed25519.PublicKey, fn func(name string, r io.Reader) error) (*Verification, error) {
+	var manifestData, sigData []byte
+	found := map[string]Entry{}
+	var order []string
+	v := &Verification{}
+
+	err := r.Walk(func(name string, rd io.Reader) error {
+		switch name {
+		case ManifestName:
+			data, err := io.ReadAll(rd)
+			manifestData = data
+			return err
+		case SignatureName:
+			data, err := io.ReadAll(rd)
+			sigData = data
+			return err
+		}
+		hc := &hashCounter{h: sha256.New()}
+		tee := io.TeeReader(rd, hc)
+		if fn != nil {
+			if err := fn(name, tee); err != nil {
+				return err
+			}
+		}
+		if _, err := io.Copy(io.Discard, tee); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if _, dup := found[name]; dup {
+			v.problem("%s: stored more than once", name)
+		} else {
+			order = append(order, name)
+		}
+		found[name] = Entry{Name: name, Size: hc.n, SHA256: hex.EncodeToString(hc.h.Sum(nil))}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if manifestData == nil {
+		v.problem("%s is missing", ManifestName)
+		return v, nil
+	}
+	var m Manifest
+	if err := json.Unmarshal(manifestData, &m); err != nil {
+		v.problem("%s is not valid: %v", ManifestName, err)
+		return v, nil
+	}
+	v.Manifest = &m
+
+	listed := make(map[string]bool, len(m.Entries))
+	for _, want := range m.Entries {
+		listed[want.Name] = true
+		got, ok := found[want.Name]
+		switch {
+		case !ok:
+			v.problem("%s: listed in the manifest but missing", want.Name)
+		case got.Size != want.Size || got.SHA256 != want.SHA256:
+			v.problem("%s: content does not match the manifest hash", want.Name)
+		}
+	}
+	for _, name := range order {
+		if !listed[name] {
+			v.problem("%s: not listed in the manifest", name)
+		}
+	}
+
+	v.verifySignature(manifestData, sigData, trusted)
+	return v, nil
+}
+
+// verifySignature checks the detached manifest signature.
+func (v *Verification) verifySignature(manifest, sigData []byte, trusted ed25519.PublicKey) {
+	if sigData == nil {
+		if trusted != nil {
+			v.problem("archive is not signed")
+		}
+		return
+	}
+	var sig Signature
+	if err := json.Unmarshal(sigData, &sig); err != nil {
+		v.problem("%s is not valid: %v", SignatureName, err)
+		return
+	}
+	if sig.Algorithm != "ed25519" || len(sig.PublicKey) != ed25519.PublicKeySize {
+		v.problem("%s: unsupported signature algorithm %q", SignatureName, sig.Algorithm)
+		return
+	}
+	pub := ed25519.PublicKey(sig.PublicKey)
+	if !ed25519.Verify(pub, manifest, sig.Signature) {
+		v.problem("manifest signature is invalid")
+		return
+	}
+	v.Signer = pub
+	if trusted != nil && !trusted.Equal(pub) {
+		v.problem("manifest is signed with untrusted key %s", Fingerprint(pub))
+	}
+}
@@ -0,0 +1,192 @@
+// Package baseline reads a previous collection archive so that an
+// incremental collection can carry forward the responses that did not
+// change since.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/redact"
+	"ndfc-collector/pkg/requests"
+)
+
+// MetaKey is the manifest metadata key describing the baseline of an
+// incremental collection.
+const MetaKey = "baseline"
+
+// Info describes the baseline an incremental collection was made against.
+type Info struct {
+	Archive string    `json:"archive"` // file name of the baseline archive
+	Created time.Time `json:"created"` // when the baseline was collected
+	Depth   int       `json:"depth"`   // incremental collections since the last full one, counting this one
+}
+
+// Baseline is a previous collection archive. Open reads its manifest and
+// Load makes the entries of its successful requests available.
+type Baseline struct {
+	Info Info // to record in the manifest of a collection against the baseline
+
+	path    string
+	sums    map[string]string          // SHA-256 of each entry, from the manifest
+	results map[string]requests.Result // successful requests by entry name
+	dir     string                     // extracted entries
+}
+
+// Open reads the manifest of the archive at path. Redacted archives cannot
+// be baselines, since their entries no longer match what NDFC returns.
+func Open(path string) (*Baseline, error) {
+	r, err := archive.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := archive.ReadFile(r, archive.ManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read manifest of %s: %w", path, err)
+	}
+	var m struct {
+		Created time.Time                  `json:"created"`
+		Meta    map[string]json.RawMessage `json:"meta"`
+		Entries []archive.Entry            `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest in %s: %w", path, err)
+	}
+	if string(m.Meta[redact.MetaKey]) == "true" {
+		return nil, fmt.Errorf("%s is redacted and cannot be used as a baseline", path)
+	}
+	raw, ok := m.Meta[requests.MetaKey]
+	if !ok {
+		return nil, errors.New(path + " has no request results; it was collected by an older version")
+	}
+	var results []requests.Result
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return nil, fmt.Errorf("invalid request results in %s: %w", path, err)
+	}
+	var prev Info
+	if raw, ok := m.Meta[MetaKey]; ok {
+		if err := json.Unmarshal(raw, &prev); err != nil {
+			return nil, fmt.Errorf("invalid baseline in %s: %w", path, err)
+		}
+	}
+
+	b := &Baseline{
+		Info:    Info{Archive: filepath.Base(path), Created: m.Created, Depth: prev.Depth + 1},
+		path:    path,
+		sums:    make(map[string]string, len(m.Entries)),
+		results: make(map[string]requests.Result, len(results)),
+	}
+	for _, e := range m.Entries {
+		b.sums[e.Name] = e.SHA256
+	}
+	for _, res := range results {
+		if res.Error == "" {
+			b.results[res.Entry] = res
+		}
+	}
+	return b, nil
+}
+
+// Load extracts the entries of the successful requests to a temporary
+// directory, which Close removes. Entries whose content does not match
+// the manifest are left out, so that they are collected again.
+func (b *Baseline) Load() error {
+	r, err := archive.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if b.dir, err = os.MkdirTemp("", "ndfc-collector-baseline-*"); err != nil {
+		return err
+	}
+	loaded := make(map[string]bool, len(b.results))
+	err = r.Walk(func(name string, rd io.Reader) error {
+		if _, ok := b.results[name]; !ok || !filepath.IsLocal(name) {
+			return nil
+		}
+		path := filepath.Join(b.dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		_, err = io.Copy(io.MultiWriter(f, h), rd)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if sum, ok := b.sums[name]; ok && sum != hex.EncodeToString(h.Sum(nil)) {
+			return os.Remove(path)
+		}
+		loaded[name] = true
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", b.path, err)
+	}
+	for name := range b.results {
+		if !loaded[name] {
+			delete(b.results, name)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the result of the request stored as entry, if it
+// succeeded and its content is available. A nil Baseline has none.
+func (b *Baseline) Lookup(entry string) (requests.Result, bool) {
+	if b == nil {
+		return requests.Result{}, false
+	}
+	res, ok := b.results[entry]
+	return res, ok
+}
+
+// Len returns the number of entries available.
+func (b *Baseline) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.results)
+}
+
+// OpenEntry opens the content of entry, which Lookup must have found.
+func (b *Baseline) OpenEntry(entry string) (*os.File, error) {
+	return os.Open(filepath.Join(b.dir, entry))
+}
+
+// Close removes the extracted entries.
+func (b *Baseline) Close() error {
+	if b == nil || b.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(b.dir)
+}
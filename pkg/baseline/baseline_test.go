@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseline
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/ndfc"
+	"ndfc-collector/pkg/redact"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeArchive writes a collection with a successful and a failed request.
+func writeArchive(t *testing.T, name string, format archive.Format, meta map[string]any) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	arc, err := archive.Create(path, format)
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("manage.fabrics.json", []byte(`{"fabrics":[]}`)))
+	require.NoError(t, arc.Add("fabrics.f1.vrfs.json", []byte(`[{"id":1}]`)))
+	arc.SetMeta(requests.MetaKey, []requests.Result{
+		{Entry: "manage.fabrics.json", URL: "/fabrics"},
+		{Entry: "fabrics.f1.vrfs.json", URL: "/fabrics/f1/vrfs", Validators: ndfc.Validators{ETag: `"v1"`}},
+		{Entry: "fabrics.f2.vrfs.json", URL: "/fabrics/f2/vrfs", Error: "HTTP 500"},
+	})
+	for k, v := range meta {
+		arc.SetMeta(k, v)
+	}
+	require.NoError(t, arc.Close())
+	return path
+}
+
+func TestOpenAndLoad(t *testing.T) {
+	path := writeArchive(t, "full.zip", archive.FormatZip, nil)
+	b, err := Open(path)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, "full.zip", b.Info.Archive)
+	assert.Equal(t, 1, b.Info.Depth, "the first collection against a full one")
+	assert.False(t, b.Info.Created.IsZero())
+
+	require.NoError(t, b.Load())
+	assert.Equal(t, 2, b.Len())
+	res, ok := b.Lookup("fabrics.f1.vrfs.json")
+	require.True(t, ok)
+	assert.Equal(t, `"v1"`, res.ETag)
+	_, ok = b.Lookup("fabrics.f2.vrfs.json")
+	assert.False(t, ok, "failed requests are not carried forward")
+
+	f, err := b.OpenEntry("fabrics.f1.vrfs.json")
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":1}]`, string(data))
+
+	dir := b.dir
+	require.NoError(t, b.Close())
+	assert.NoDirExists(t, dir)
+}
+
+func TestLoadSkipsCorruptEntries(t *testing.T) {
+	path := writeArchive(t, "full", archive.FormatDir, nil)
+	require.NoError(t, os.WriteFile(filepath.Join(path, "fabrics.f1.vrfs.json"), []byte(`[]`), 0o644))
+	b, err := Open(path)
+	require.NoError(t, err)
+	defer b.Close()
+	require.NoError(t, b.Load())
+	_, ok := b.Lookup("fabrics.f1.vrfs.json")
+	assert.False(t, ok)
+	_, ok = b.Lookup("manage.fabrics.json")
+	assert.True(t, ok)
+}
+
+func TestOpenDepth(t *testing.T) {
+	path := writeArchive(t, "incr.zip", archive.FormatZip, map[string]any{
+		MetaKey: Info{Archive: "full.zip", Depth: 2},
+	})
+	b, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, b.Info.Depth)
+	var nilBase *Baseline
+	_, ok := nilBase.Lookup("manage.fabrics.json")
+	assert.False(t, ok)
+	assert.NoError(t, nilBase.Close())
+}
+
+func TestOpenRejects(t *testing.T) {
+	_, err := Open(writeArchive(t, "redacted.zip", archive.FormatZip, map[string]any{redact.MetaKey: true}))
+	assert.ErrorContains(t, err, "redacted")
+
+	path := filepath.Join(t.TempDir(), "old.zip")
+	arc, err := archive.Create(path, archive.FormatZip)
+	require.NoError(t, err)
+	require.NoError(t, arc.Close())
+	_, err = Open(path)
+	assert.ErrorContains(t, err, "no request results")
+}
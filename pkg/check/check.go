@@ -0,0 +1,278 @@
+// Package check evaluates declarative health checks against collected data.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity ranks findings.
+type Severity string
+
+// Severities, from least to most severe.
+const (
+	Info     Severity = "info"
+	Warning  Severity = "warning"
+	Critical Severity = "critical"
+)
+
+var severityRank = map[Severity]int{Info: 1, Warning: 2, Critical: 3}
+
+// ParseSeverity validates a severity name.
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(s))
+	if _, ok := severityRank[sev]; !ok {
+		return "", fmt.Errorf("unknown severity %q", s)
+	}
+	return sev, nil
+}
+
+// AtLeast reports whether s is at least as severe as min.
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRank[s] >= severityRank[min]
+}
+
+// Operators comparing the value at a check's path with its value. A
+// finding is raised when the comparison holds.
+const (
+	OpEq        = "eq"         // equal, numerically when both sides are numbers
+	OpNe        = "ne"         // not equal
+	OpGt        = "gt"         // greater than
+	OpGe        = "ge"         // greater than or equal
+	OpLt        = "lt"         // less than
+	OpLe        = "le"         // less than or equal
+	OpContains  = "contains"   // contains the substring
+	OpMatches   = "matches"    // matches the regular expression
+	OpExists    = "exists"     // path is present
+	OpMissing   = "missing"    // path is absent
+	OpOlderThan = "older_than" // timestamp is older than the duration, e.g. 7d
+)
+
+// Match modes deciding how item results turn into findings.
+const (
+	MatchAny = "any" // one finding per matching item
+	MatchAll = "all" // one finding per db_key when every item matches
+)
+
+// Check is a single declarative health check.
+type Check struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	DBKey       string   `yaml:"db_key"`   // db_key template or resolved db_key of the items to check
+	Path        string   `yaml:"path"`     // gjson path within each item
+	Op          string   `yaml:"op"`       // comparison, see the Op constants
+	Value       string   `yaml:"value"`    // operand for the comparison
+	Match       string   `yaml:"match"`    // any (default) or all
+	Severity    Severity `yaml:"severity"` // info, warning (default) or critical
+	Message     string   `yaml:"message"`  // finding text; {path} is replaced from the item
+
+	re  *regexp.Regexp
+	num float64
+	age time.Duration
+}
+
+//go:embed checks.yaml
+var defaultChecksYAML []byte
+
+// DefaultChecks returns the built-in checks.
+func DefaultChecks() ([]Check, error) {
+	return parseChecks(defaultChecksYAML)
+}
+
+// LoadChecks reads checks from a YAML file in the same format as the
+// built-in checks.yaml.
+func LoadChecks(path string) ([]Check, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading checks: %w", err)
+	}
+	return parseChecks(data)
+}
+
+func parseChecks(data []byte) ([]Check, error) {
+	var raw struct {
+		Checks []Check `yaml:"checks"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing checks: %w", err)
+	}
+	return raw.Checks, nil
+}
+
+// compile validates c and fills in defaults.
+func (c *Check) compile() error {
+	if c.Name == "" || c.DBKey == "" || c.Path == "" {
+		return fmt.Errorf("name, db_key and path are required")
+	}
+	if c.Severity == "" {
+		c.Severity = Warning
+	}
+	sev, err := ParseSeverity(string(c.Severity))
+	if err != nil {
+		return err
+	}
+	c.Severity = sev
+	switch c.Match {
+	case "":
+		c.Match = MatchAny
+	case MatchAny, MatchAll:
+	default:
+		return fmt.Errorf("unknown match %q", c.Match)
+	}
+	if c.Message == "" {
+		c.Message = c.Description
+	}
+
+	switch c.Op {
+	case OpEq, OpNe, OpContains, OpExists, OpMissing:
+	case OpGt, OpGe, OpLt, OpLe:
+		if c.num, err = strconv.ParseFloat(c.Value, 64); err != nil {
+			return fmt.Errorf("%s needs a numeric value, got %q", c.Op, c.Value)
+		}
+	case OpMatches:
+		if c.re, err = regexp.Compile(c.Value); err != nil {
+			return err
+		}
+	case OpOlderThan:
+		if c.age, err = parseAge(c.Value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown op %q", c.Op)
+	}
+	return nil
+}
+
+// parseAge parses a duration, additionally accepting days such as "7d".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// timeLayouts are the timestamp formats accepted by older_than, besides
+// Unix times in seconds or milliseconds.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000-0700",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseTime reads a timestamp from v.
+func parseTime(v gjson.Result) (time.Time, bool) {
+	if v.Type == gjson.Number {
+		n := v.Int()
+		if n > 1e11 {
+			return time.UnixMilli(n), true
+		}
+		return time.Unix(n, 0), true
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, v.String()); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// number reads v as a number, including numeric strings.
+func number(v gjson.Result) (float64, bool) {
+	switch v.Type {
+	case gjson.Number:
+		return v.Num, true
+	case gjson.String:
+		f, err := strconv.ParseFloat(v.Str, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// test reports whether the value at c's path raises a finding.
+func (c *Check) test(v gjson.Result, now time.Time) bool {
+	switch c.Op {
+	case OpExists:
+		return v.Exists()
+	case OpMissing:
+		return !v.Exists()
+	}
+	if !v.Exists() {
+		return false
+	}
+	switch c.Op {
+	case OpEq, OpNe:
+		equal := v.String() == c.Value
+		if n, ok := number(v); ok {
+			if want, err := strconv.ParseFloat(c.Value, 64); err == nil {
+				equal = n == want
+			}
+		}
+		return equal == (c.Op == OpEq)
+	case OpGt, OpGe, OpLt, OpLe:
+		n, ok := number(v)
+		if !ok {
+			return false
+		}
+		switch c.Op {
+		case OpGt:
+			return n > c.num
+		case OpGe:
+			return n >= c.num
+		case OpLt:
+			return n < c.num
+		}
+		return n <= c.num
+	case OpContains:
+		return strings.Contains(v.String(), c.Value)
+	case OpMatches:
+		return c.re.MatchString(v.String())
+	case OpOlderThan:
+		t, ok := parseTime(v)
+		return ok && now.Sub(t) > c.age
+	}
+	return false
+}
+
+var messageField = regexp.MustCompile(`\{([^}]+)\}`)
+
+// message renders c's message for item.
+func (c *Check) message(item []byte) string {
+	return messageField.ReplaceAllStringFunc(c.Message, func(m string) string {
+		return gjson.GetBytes(item, m[1:len(m)-1]).String()
+	})
+}
@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/export"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+var testNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestDefaultChecks(t *testing.T) {
+	checks, err := DefaultChecks()
+	require.NoError(t, err)
+	require.NotEmpty(t, checks)
+	_, err = New(checks, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, "0", checks[0].Value, "numeric YAML values are read as strings")
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for name, c := range map[string]Check{
+		"missing path": {Name: "x", DBKey: "k", Op: OpEq},
+		"unknown op":   {Name: "x", DBKey: "k", Path: "p", Op: "like"},
+		"numeric":      {Name: "x", DBKey: "k", Path: "p", Op: OpGt, Value: "many"},
+		"regex":        {Name: "x", DBKey: "k", Path: "p", Op: OpMatches, Value: "("},
+		"duration":     {Name: "x", DBKey: "k", Path: "p", Op: OpOlderThan, Value: "soon"},
+		"severity":     {Name: "x", DBKey: "k", Path: "p", Op: OpExists, Severity: "fatal"},
+		"match":        {Name: "x", DBKey: "k", Path: "p", Op: OpExists, Match: "most"},
+	} {
+		_, err := New([]Check{c}, testNow)
+		assert.Error(t, err, name)
+	}
+	_, err := New([]Check{
+		{Name: "x", DBKey: "k", Path: "p", Op: OpExists},
+		{Name: "x", DBKey: "k", Path: "q", Op: OpExists},
+	}, testNow)
+	assert.ErrorContains(t, err, "duplicate")
+}
+
+func TestCheck_Test(t *testing.T) {
+	for _, tc := range []struct {
+		op, value, item string
+		want            bool
+	}{
+		{OpEq, "inSync", `{"v":"inSync"}`, true},
+		{OpNe, "inSync", `{"v":"inSync"}`, false},
+		{OpNe, "inSync", `{"v":"outOfSync"}`, true},
+		{OpEq, "1", `{"v":1.0}`, true},
+		{OpGt, "10", `{"v":11}`, true},
+		{OpGt, "10", `{"v":"11"}`, true},
+		{OpGt, "10", `{"v":10}`, false},
+		{OpGe, "10", `{"v":10}`, true},
+		{OpLt, "10", `{"v":"n/a"}`, false},
+		{OpLe, "0", `{"v":[]}`, false},
+		{OpGt, "1", `{"v":[1,2,3]}`, false},
+		{OpContains, "Fail", `{"v":"AuthFailed"}`, true},
+		{OpMatches, "^(?i)unassigned$", `{"v":"UNASSIGNED"}`, true},
+		{OpExists, "", `{"v":null}`, true},
+		{OpMissing, "", `{"w":1}`, true},
+		{OpEq, "x", `{"w":1}`, false},
+		{OpOlderThan, "7d", `{"v":"2026-03-01T00:00:00Z"}`, true},
+		{OpOlderThan, "7d", `{"v":"2026-03-09 08:00:00"}`, false},
+		{OpOlderThan, "24h", `{"v":1772928000000}`, true}, // 2026-03-08 in milliseconds
+		{OpOlderThan, "24h", `{"v":"not a time"}`, false},
+	} {
+		c := Check{Name: "x", DBKey: "k", Path: "v", Op: tc.op, Value: tc.value}
+		require.NoError(t, c.compile())
+		assert.Equal(t, tc.want, c.test(gjson.Get(tc.item, "v"), testNow), "%s %s %s", tc.op, tc.value, tc.item)
+	}
+}
+
+func testReport(t *testing.T) *Report {
+	t.Helper()
+	e, err := New([]Check{
+		{
+			Name: "sync", Description: "Switch out of sync", DBKey: "fabrics/{fabricName}/switches",
+			Path: "status", Op: OpNe, Value: "inSync", Message: "{name} is {status}",
+		},
+		{
+			Name: "backup", Description: "No recent backup", DBKey: "infra/backups",
+			Path: "created", Op: OpOlderThan, Value: "7d", Match: MatchAll, Severity: Critical,
+		},
+		{Name: "licenses", DBKey: "infra/license/assignments", Path: "state", Op: OpEq, Value: "unassigned"},
+	}, testNow)
+	require.NoError(t, err)
+	for _, rec := range []export.Record{
+		{DBKey: "fabrics/f1/switches", Template: "fabrics/{fabricName}/switches", ID: "s1", Item: json.RawMessage(`{"name":"leaf1","status":"inSync"}`)},
+		{DBKey: "fabrics/f1/switches", Template: "fabrics/{fabricName}/switches", ID: "s2", Item: json.RawMessage(`{"name":"leaf2","status":"outOfSync"}`)},
+		{DBKey: "infra/backups", Template: "infra/backups", ID: "b1", Item: json.RawMessage(`{"created":"2026-01-01T00:00:00Z"}`)},
+		{DBKey: "infra/backups", Template: "infra/backups", ID: "b2", Item: json.RawMessage(`{"created":"2026-02-01T00:00:00Z"}`)},
+	} {
+		e.Observe(rec)
+	}
+	return e.Report()
+}
+
+func TestEngine(t *testing.T) {
+	r := testReport(t)
+	require.Len(t, r.Results, 3)
+	assert.Equal(t, 2, r.Results[0].Evaluated)
+	assert.Equal(t, []Finding{
+		{Check: "backup", Severity: Critical, DBKey: "infra/backups", Value: "2026-02-01T00:00:00Z", Message: "No recent backup"},
+		{Check: "sync", Severity: Warning, DBKey: "fabrics/f1/switches", ID: "s2", Value: "outOfSync", Message: "leaf2 is outOfSync"},
+	}, r.Findings())
+	assert.Equal(t, 1, r.Count(Critical))
+	assert.Equal(t, 2, r.Count(Info))
+
+	// A single recent backup clears the match: all check.
+	e, err := New([]Check{r.Results[1].Check}, testNow)
+	require.NoError(t, err)
+	e.Observe(export.Record{DBKey: "infra/backups", Template: "infra/backups", Item: json.RawMessage(`{"created":"2026-01-01T00:00:00Z"}`)})
+	e.Observe(export.Record{DBKey: "infra/backups", Template: "infra/backups", Item: json.RawMessage(`{"created":"2026-03-09T00:00:00Z"}`)})
+	assert.Empty(t, e.Report().Findings())
+}
+
+func TestRun_NoBackups(t *testing.T) {
+	catalog := []requests.Request{{URL: "/api/v1/infra/backups", DBKey: "infra/backups", ListPath: "backups", IDField: "name"}}
+	dir := filepath.Join(t.TempDir(), "out")
+	arc, err := archive.NewDirWriter(dir)
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("infra.backups.json", []byte(`{"backups":[]}`)))
+	arc.SetMeta(requests.MetaKey, []requests.Result{
+		{Entry: "infra.backups.json", URL: "/api/v1/infra/backups", Template: "/api/v1/infra/backups", DBKey: "infra/backups"},
+	})
+	require.NoError(t, arc.Close())
+
+	checks := []Check{{
+		Name: "backup-age", Description: "No backup was taken in the last 7 days", DBKey: "infra/backups",
+		Path: "createdTime", Op: OpOlderThan, Value: "7d", Match: MatchAll, Message: "No backup newer than 7 days",
+	}}
+	r, err := archive.Open(dir)
+	require.NoError(t, err)
+	defer r.Close()
+	report, err := Run(r, catalog, checks, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{
+		{Check: "backup-age", Severity: Warning, DBKey: "infra/backups", Message: "No backup newer than 7 days"},
+	}, report.Findings(), "a controller without backups raises the finding")
+}
+
+func TestReport_Warnings(t *testing.T) {
+	e, err := New([]Check{
+		{Name: "typo", DBKey: "inventory/switches", Path: "stauts", Op: OpNe, Value: "ok"},
+		{Name: "sparse", DBKey: "inventory/switches", Path: "vpc", Op: OpExists},
+	}, testNow)
+	require.NoError(t, err)
+	for _, item := range []string{`{"status":"ok"}`, `{"status":"ok","vpc":{}}`} {
+		e.Observe(export.Record{DBKey: "inventory/switches", Template: "inventory/switches", Item: json.RawMessage(item)})
+	}
+	r := e.Report()
+	assert.Equal(t, []string{"check typo: path stauts is in none of the 2 items of inventory/switches"}, r.Warnings(),
+		"a path present in some items is fine")
+	require.Len(t, r.Findings(), 1, "the misspelled path matches nothing")
+	assert.Equal(t, "sparse", r.Findings()[0].Check)
+
+	var text bytes.Buffer
+	require.NoError(t, r.Write(&text, FormatText))
+	assert.Contains(t, text.String(), "\nwarning: check typo: path stauts")
+	var junit bytes.Buffer
+	require.NoError(t, r.Write(&junit, FormatJUnit))
+	assert.Contains(t, junit.String(), `<skipped message="path stauts is in none of the items of inventory/switches">`)
+	assert.Contains(t, junit.String(), `failures="1" skipped="1"`)
+}
+
+func TestReport_Formats(t *testing.T) {
+	r := testReport(t)
+
+	var text bytes.Buffer
+	require.NoError(t, r.Write(&text, FormatText))
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^CRITICAL\s+backup\s+infra/backups\s+No recent backup$`, lines[0])
+	assert.Regexp(t, `^WARNING\s+sync\s+fabrics/f1/switches:s2\s+leaf2 is outOfSync$`, lines[1])
+	assert.Equal(t, "3 checks, 2 findings (1 critical, 1 warning)", lines[2])
+
+	var js bytes.Buffer
+	require.NoError(t, r.Write(&js, FormatJSON))
+	out := gjson.Parse(js.String())
+	assert.Equal(t, int64(2), out.Get("findings.#").Int())
+	assert.Equal(t, "backup", out.Get("findings.0.check").String())
+	assert.Equal(t, int64(0), out.Get("checks.2.evaluated").Int())
+
+	var junit bytes.Buffer
+	require.NoError(t, r.Write(&junit, FormatJUnit))
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(junit.Bytes(), &suites))
+	require.Len(t, suites.Suites, 1)
+	suite := suites.Suites[0]
+	assert.Equal(t, 3, suite.Tests)
+	assert.Equal(t, 2, suite.Failures)
+	assert.Equal(t, 1, suite.Skipped)
+	assert.Equal(t, "critical", suite.Cases[1].Failure.Type)
+	assert.Contains(t, suite.Cases[0].Failure.Text, "fabrics/f1/switches:s2: leaf2 is outOfSync")
+
+	assert.Error(t, r.Write(&text, "html"))
+}
+
+func TestFormatFromName(t *testing.T) {
+	assert.Equal(t, FormatJSON, FormatFromName("findings.json"))
+	assert.Equal(t, FormatJUnit, FormatFromName("junit.XML"))
+	assert.Equal(t, FormatText, FormatFromName("findings.txt"))
+}
@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/export"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/tidwall/gjson"
+)
+
+// Finding is a problem raised by a check.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	DBKey    string   `json:"db_key"`
+	ID       string   `json:"id,omitempty"`
+	Value    string   `json:"value,omitempty"` // value found at the check's path
+	Message  string   `json:"message"`
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Check     Check     `json:"-"`
+	Evaluated int       `json:"evaluated"` // number of items checked
+	Found     int       `json:"found"`     // number of items with a value at the check's path
+	Findings  []Finding `json:"findings"`
+}
+
+// Report holds the results of every check, in the order of the checks.
+type Report struct {
+	Results []Result
+}
+
+// Findings returns every finding, most severe first.
+func (r *Report) Findings() []Finding {
+	var all []Finding
+	for _, res := range r.Results {
+		all = append(all, res.Findings...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return severityRank[all[i].Severity] > severityRank[all[j].Severity]
+	})
+	return all
+}
+
+// Warnings describes the checks whose path is in none of the items they
+// evaluated, which usually means the path is misspelled or the field is not
+// what NDFC returns.
+func (r *Report) Warnings() []string {
+	var warnings []string
+	for _, res := range r.Results {
+		if res.Evaluated > 0 && res.Found == 0 {
+			warnings = append(warnings, fmt.Sprintf("check %s: path %s is in none of the %d items of %s",
+				res.Check.Name, res.Check.Path, res.Evaluated, res.Check.DBKey))
+		}
+	}
+	return warnings
+}
+
+// Count returns the number of findings at or above min.
+func (r *Report) Count(min Severity) int {
+	n := 0
+	for _, res := range r.Results {
+		for _, f := range res.Findings {
+			if f.Severity.AtLeast(min) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Engine evaluates checks against collected records.
+type Engine struct {
+	checks []Check
+	now    time.Time
+	groups []map[string]*group // per check, keyed by resolved db_key (match: all)
+	report Report
+}
+
+// group tracks the items of one db_key for a match: all check.
+type group struct {
+	order   int
+	total   int
+	matched int
+	last    Finding
+}
+
+// New validates checks and returns an engine for them. Timestamps are
+// compared with now.
+func New(checks []Check, now time.Time) (*Engine, error) {
+	e := &Engine{now: now}
+	names := map[string]bool{}
+	for i := range checks {
+		c := checks[i]
+		if err := c.compile(); err != nil {
+			return nil, fmt.Errorf("check %d (%s): %w", i, c.Name, err)
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("check %d: duplicate name %q", i, c.Name)
+		}
+		names[c.Name] = true
+		e.checks = append(e.checks, c)
+		e.groups = append(e.groups, map[string]*group{})
+		e.report.Results = append(e.report.Results, Result{Check: c})
+	}
+	return e, nil
+}
+
+// Observe evaluates every check selecting rec.
+func (e *Engine) Observe(rec export.Record) {
+	for i := range e.checks {
+		c := &e.checks[i]
+		if c.DBKey != rec.Template && c.DBKey != rec.DBKey {
+			continue
+		}
+		res := &e.report.Results[i]
+		res.Evaluated++
+		v := gjson.GetBytes(rec.Item, c.Path)
+		if v.Exists() {
+			res.Found++
+		}
+		hit := c.test(v, e.now)
+		f := Finding{
+			Check:    c.Name,
+			Severity: c.Severity,
+			DBKey:    rec.DBKey,
+			ID:       rec.ID,
+			Value:    v.String(),
+			Message:  c.message(rec.Item),
+		}
+		if c.Match == MatchAny {
+			if hit {
+				res.Findings = append(res.Findings, f)
+			}
+			continue
+		}
+		g := e.group(i, rec.DBKey)
+		g.total++
+		if hit {
+			g.matched++
+		}
+		f.ID = ""
+		g.last = f
+	}
+}
+
+// Collected records that a response for dbKey, expanded from the db_key
+// template, was collected. A match: all check selecting it then raises its
+// finding even if the response has no items, e.g. when there are no backups.
+func (e *Engine) Collected(dbKey, template string) {
+	for i := range e.checks {
+		c := &e.checks[i]
+		if c.Match == MatchAll && (c.DBKey == template || c.DBKey == dbKey) {
+			e.group(i, dbKey)
+		}
+	}
+}
+
+// group returns the group of dbKey for the match: all check i, starting it
+// with the finding raised when there are no items.
+func (e *Engine) group(i int, dbKey string) *group {
+	g, ok := e.groups[i][dbKey]
+	if !ok {
+		c := &e.checks[i]
+		g = &group{order: len(e.groups[i]), last: Finding{
+			Check:    c.Name,
+			Severity: c.Severity,
+			DBKey:    dbKey,
+			Message:  c.message(nil),
+		}}
+		e.groups[i][dbKey] = g
+	}
+	return g
+}
+
+// Report returns the results of every check for the records observed.
+func (e *Engine) Report() *Report {
+	report := Report{Results: make([]Result, len(e.report.Results))}
+	for i, res := range e.report.Results {
+		res.Findings = append([]Finding(nil), res.Findings...)
+		var groups []*group
+		for _, g := range e.groups[i] {
+			if g.matched == g.total {
+				groups = append(groups, g)
+			}
+		}
+		sort.Slice(groups, func(a, b int) bool { return groups[a].order < groups[b].order })
+		for _, g := range groups {
+			res.Findings = append(res.Findings, g.last)
+		}
+		report.Results[i] = res
+	}
+	return &report
+}
+
+// Run evaluates checks against every record in r.
+func Run(r archive.Reader, catalog []requests.Request, checks []Check, now time.Time) (*Report, error) {
+	e, err := New(checks, now)
+	if err != nil {
+		return nil, err
+	}
+	results, err := export.Results(r)
+	if err != nil {
+		return nil, err
+	}
+	templates := make(map[string]string, len(catalog)) // catalog URL -> db_key template
+	for _, req := range catalog {
+		templates[req.URL] = req.DBKey
+	}
+	for _, res := range results {
+		if res.Error != "" {
+			continue
+		}
+		dbKey, template := res.DBKey, templates[res.Template]
+		if dbKey == "" {
+			dbKey = res.URL
+		}
+		if template == "" {
+			template = dbKey
+		}
+		e.Collected(dbKey, template)
+	}
+	err = export.Walk(r, catalog, func(rec export.Record) error {
+		e.Observe(rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.Report(), nil
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// Report formats.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// FormatFromName infers the report format from a file name: .json for
+// JSON, .xml for JUnit XML and text otherwise.
+func FormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return FormatJSON
+	case ".xml":
+		return FormatJUnit
+	}
+	return FormatText
+}
+
+// Write writes the report to w in format.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatText:
+		return r.WriteText(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatJUnit:
+		return r.WriteJUnit(w)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+// summary returns e.g. "6 checks, 3 findings (1 critical, 2 warning)".
+func (r *Report) summary() string {
+	findings := r.Findings()
+	counts := map[Severity]int{}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	var parts []string
+	for _, sev := range []Severity{Critical, Warning, Info} {
+		if counts[sev] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[sev], sev))
+		}
+	}
+	s := fmt.Sprintf("%d checks, %d findings", len(r.Results), len(findings))
+	if len(parts) > 0 {
+		s += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return s
+}
+
+// WriteText writes one line per finding, most severe first, a summary and
+// the report's warnings.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range r.Findings() {
+		key := f.DBKey
+		if f.ID != "" {
+			key += ":" + f.ID
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", strings.ToUpper(string(f.Severity)), f.Check, key, f.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, r.summary()); err != nil {
+		return err
+	}
+	for _, warning := range r.Warnings() {
+		if _, err := fmt.Fprintln(w, "warning: "+warning); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the findings and per-check results as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	type checkJSON struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description,omitempty"`
+		Severity    Severity `json:"severity"`
+		Evaluated   int      `json:"evaluated"`
+		Found       int      `json:"found"`
+		Findings    int      `json:"findings"`
+	}
+	out := struct {
+		Summary  string      `json:"summary"`
+		Checks   []checkJSON `json:"checks"`
+		Findings []Finding   `json:"findings"`
+		Warnings []string    `json:"warnings,omitempty"`
+	}{Summary: r.summary(), Checks: []checkJSON{}, Findings: r.Findings(), Warnings: r.Warnings()}
+	if out.Findings == nil {
+		out.Findings = []Finding{}
+	}
+	for _, res := range r.Results {
+		out.Checks = append(out.Checks, checkJSON{
+			Name:        res.Check.Name,
+			Description: res.Check.Description,
+			Severity:    res.Check.Severity,
+			Evaluated:   res.Evaluated,
+			Found:       res.Found,
+			Findings:    len(res.Findings),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes a JUnit XML report with one test case per check.
+// Checks with findings fail, and checks without any matching items, or
+// whose path is in none of them, are skipped.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitSuite{Name: "ndfc-collector checks", Tests: len(r.Results)}
+	for _, res := range r.Results {
+		tc := junitCase{Name: res.Check.Name, Classname: res.Check.DBKey}
+		switch {
+		case len(res.Findings) > 0:
+			var lines []string
+			for _, f := range res.Findings {
+				key := f.DBKey
+				if f.ID != "" {
+					key += ":" + f.ID
+				}
+				lines = append(lines, key+": "+f.Message)
+			}
+			tc.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d findings: %s", len(res.Findings), res.Check.Description),
+				Type:    string(res.Check.Severity),
+				Text:    strings.Join(lines, "\n"),
+			}
+			suite.Failures++
+		case res.Evaluated == 0:
+			tc.Skipped = &junitMessage{Message: "no data collected for " + res.Check.DBKey}
+			suite.Skipped++
+		case res.Found == 0:
+			tc.Skipped = &junitMessage{Message: "path " + res.Check.Path + " is in none of the items of " + res.Check.DBKey}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -17,36 +17,51 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"net/http"
 	"path"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"ndfc-collector/pkg/archive"
 	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/jsonstream"
+	"ndfc-collector/pkg/log"
 	"ndfc-collector/pkg/ndfc"
 	"ndfc-collector/pkg/requests"
+	"ndfc-collector/pkg/trace"
 
 	"github.com/brightpuddle/gobits/errors"
-	"github.com/brightpuddle/gobits/log"
 
 	"github.com/tidwall/gjson"
 )
 
-// GetClient creates an NDFC host client
-func GetClient(cfg *config.Config) (ndfc.Client, error) {
+// GetClient creates an NDFC host client, logging the login and version
+// detection to logger.
+func GetClient(cfg *config.Config, logger log.Logger) (ndfc.Client, error) {
 	// Sanitize username against quotes
 	cfg.Password = strings.ReplaceAll(cfg.Password, "\"", "\\\"")
-	client, err := ndfc.NewClient(
-		cfg.URL, cfg.Username, cfg.Password,
-		ndfc.RequestTimeout(600),
-	)
+	mods := []func(*ndfc.Client){ndfc.RequestTimeout(600)}
+	switch {
+	case cfg.AdaptiveRate:
+		mods = append(mods, ndfc.AdaptiveRateLimit(cfg.RateLimit, cfg.RateBurst))
+	case cfg.RateLimit > 0:
+		mods = append(mods, ndfc.RateLimit(cfg.RateLimit, cfg.RateBurst))
+	}
+	if cache, err := openCache(cfg); err != nil {
+		logger.Warn().Err(err).Msg("Cannot use the response cache; downloading every response in full.")
+	} else if cache != nil {
+		mods = append(mods, ndfc.UseCache(cache))
+	}
+	client, err := ndfc.NewClient(cfg.URL, cfg.Username, cfg.Password, mods...)
 	if err != nil {
 		return ndfc.Client{}, errors.WithStack(fmt.Errorf("failed to create NDFC client: %v", err))
 	}
 
-	logger := log.New()
-
 	// Authenticate
 	logger.Info().Str("host", cfg.URL).Msg("NDFC host")
 	logger.Info().Str("user", cfg.Username).Msg("NDFC username")
@@ -56,87 +71,392 @@ func GetClient(cfg *config.Config) (ndfc.Client, error) {
 			fmt.Errorf("cannot authenticate to NDFC at %s: %v", cfg.URL, err),
 		)
 	}
+
+	if cfg.ControllerVersion != "" {
+		if client.Version, err = ndfc.ParseVersion(cfg.ControllerVersion); err != nil {
+			return ndfc.Client{}, errors.WithStack(fmt.Errorf("invalid controller_version: %v", err))
+		}
+	} else if client.Version, err = client.GetVersion(); err != nil {
+		logger.Warn().Err(err).Msg("Cannot detect the NDFC version; sending every request. Set controller_version to select requests for a release.")
+	}
+	if !client.Version.IsZero() {
+		logger.Info().Str("version", client.Version.String()).Msg("NDFC version")
+	}
 	return client, nil
 }
 
+// openCache opens the response cache, or returns nil unless it is turned on
+// with cache. The cache holds responses as NDFC sent them, so it is not used
+// for encrypted or redacted archives either.
+func openCache(cfg *config.Config) (*ndfc.Cache, error) {
+	if !cfg.Cache || cfg.Redact || len(cfg.EncryptRecipients) > 0 || cfg.EncryptPassphrase != "" {
+		return nil, nil
+	}
+	dir := cfg.CacheDir
+	if dir == "" {
+		var err error
+		if dir, err = ndfc.DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	return ndfc.OpenCache(dir, time.Duration(cfg.CacheMaxAge)*24*time.Hour)
+}
+
+// requestID numbers requests for the request_id log field.
+var requestID atomic.Uint64
+
+// ErrNotModified is returned by FetchResult when NDFC answers a conditional
+// request with HTTP 304. Nothing is stored in the archive; the caller
+// carries the baseline response forward instead.
+var ErrNotModified = errors.New("response not modified")
+
+// Response describes a response stored by FetchResult.
+type Response struct {
+	Items      gjson.Result    // list items reduced to the requested keys
+	Validators ndfc.Validators // cache validators sent by NDFC, if any
+}
+
+// stream is the response to one attempt of a request, read as NDFC sends it.
+type stream struct {
+	*bufio.Reader
+	pr   *io.PipeReader
+	done chan fetched
+}
+
+// fetched is the outcome of streaming a response.
+type fetched struct {
+	n   int64 // bytes received from NDFC
+	err error
+}
+
+// openStream starts streaming the response for path and waits for the first byte
+// of the body, so that requests failing before NDFC sends a body can be
+// retried. With normalize the body is read into memory and rewritten as
+// canonical JSON before it is passed on.
+func openStream(client ndfc.Client, path string, request requests.Request, normalize bool, mods []func(*ndfc.Req), logger log.Logger) (*stream, error) {
+	pr, pw := io.Pipe()
+	b := &stream{Reader: bufio.NewReader(pr), pr: pr, done: make(chan fetched, 1)}
+	go func() {
+		var res fetched
+		if normalize {
+			var buf bytes.Buffer
+			if res.n, res.err = client.GetStream(path, &buf, mods...); res.err == nil {
+				res.err = normalizeTo(pw, buf.Bytes(), request, logger)
+			}
+		} else {
+			res.n, res.err = client.GetStream(path, pw, mods...)
+		}
+		pw.CloseWithError(res.err)
+		b.done <- res
+	}()
+	if _, err := b.Peek(1); err != nil && err != io.EOF {
+		<-b.done
+		return nil, err
+	}
+	return b, nil
+}
+
+// close stops the stream, failing it with err if it has not finished, and
+// returns its outcome.
+func (b *stream) close(err error) fetched {
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+	b.pr.CloseWithError(err)
+	return <-b.done
+}
+
+// fetchWithRetry opens the response for path, retrying for as long as NDFC
+// has not started sending the body. Retries are counted against template in
+// the run's metrics. It returns the number of attempts made and the HTTP
+// status of the last one, or 0 if NDFC did not answer. A 304 answer to a
+// conditional request is not retried and is returned as ErrNotModified.
 func fetchWithRetry(
+	run *Run,
 	client ndfc.Client,
-	path string,
+	request requests.Request,
+	template string,
 	cfg *config.Config,
 	mods []func(*ndfc.Req),
-) (gjson.Result, error) {
-	res, err := client.Get(path, mods...)
-
-	logger := log.New()
+	logger log.Logger,
+) (*stream, int, int, error) {
+	path := request.URL
+	attempt := 1
+	b, err := openStream(client, path, request, cfg.Normalize, mods, logger)
 
 	// Retry for requestRetryCount times
-	for retries := 0; err != nil && retries < cfg.RequestRetryCount; retries++ {
-		logger.Warn().Err(err).Msgf("request failed for %s. Retrying after %d seconds.",
-			path, cfg.RetryDelay)
+	for ; err != nil && statusCode(err) != http.StatusNotModified && attempt <= cfg.RequestRetryCount; attempt++ {
+		logger.Warn().Err(err).
+			Int("attempt", attempt).
+			Int("status", statusCode(err)).
+			Msgf("request failed for %s. Retrying after %d seconds.", path, cfg.RetryDelay)
+		run.Metrics.Retry(template)
 		time.Sleep(time.Second * time.Duration(cfg.RetryDelay))
-		res, err = client.Get(path, mods...)
+		b, err = openStream(client, path, request, cfg.Normalize, mods, logger)
+	}
+	if statusCode(err) == http.StatusNotModified {
+		return nil, attempt, http.StatusNotModified, ErrNotModified
 	}
 	if err != nil {
-		return res, errors.WithStack(fmt.Errorf("request failed for %s: %v", path, err))
+		return nil, attempt, statusCode(err),
+			errors.WithStack(fmt.Errorf("request failed for %s: %v", path, err))
 	}
-	return res, nil
+	return b, attempt, http.StatusOK, nil
 }
 
-// FetchResult fetches data via API, writes it to the provided archive, and returns the result.
+// statusCode returns the HTTP status carried by err, or 0 if there is none.
+func statusCode(err error) int {
+	var statusErr *ndfc.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code
+	}
+	return 0
+}
+
+// FetchResult fetches data via API and streams it into the provided archive.
+// The body is written to the archive entry as it arrives, and when keys is
+// non-empty the list items at the request's list_path are projected in the
+// same pass and returned, reduced to just those keys, for expanding
+// dependent requests; otherwise the returned items are
+// empty. A request failing once the body has started is not retried, since
+// part of it is already in the archive. Requests with IfChanged set are
+// conditional and may return ErrNotModified, leaving the request in flight
+// on the progress display until Carry stores the baseline response.
 func FetchResult(
+	run *Run,
 	client ndfc.Client,
 	request requests.Request,
+	keys []string,
 	arc archive.Writer,
 	cfg *config.Config,
-) (gjson.Result, error) {
-	// Request URLs are stored as full host-relative paths derived from the OpenAPI specs.
-	fullPath := request.URL
+) (resp Response, err error) {
 	startTime := time.Now()
 
-	logger := log.New()
+	logger := requestLogger(run.Log, request)
 
-	// Convert URL to filename using db_key when available for human-readable names.
-	// db_key "inventory/switches" -> "inventory.switches.json"
-	// Falls back to URL-based naming for requests without a db_key.
-	filename := dbKeyToFilename(request.DBKey)
-	if filename == "" {
-		filename = urlToFilename(request.URL)
-	}
+	filename := EntryName(request)
 
 	logger.Debug().Time("start_time", startTime).Msgf("begin: %s", filename)
+	run.Progress.Begin(filename)
+	defer func() {
+		if !errors.Is(err, ErrNotModified) {
+			run.Progress.Done(filename)
+		}
+	}()
+	span := startSpan(run, request, filename)
+	defer span.End()
 	logger.Debug().Msgf("fetching %s...", filename)
 
-	mods := []func(*ndfc.Req){}
+	mods := []func(*ndfc.Req){ndfc.SaveValidators(&resp.Validators)}
 	for k, v := range request.Query {
 		mods = append(mods, ndfc.Query(k, v))
 	}
+	if !request.IfChanged.IsZero() {
+		mods = append(mods, ndfc.IfChanged(request.IfChanged))
+	}
 
-	res, err := fetchWithRetry(client, fullPath, cfg, mods)
+	template := metricsTemplate(request)
+	b, attempts, status, err := fetchWithRetry(run, client, request, template, cfg, mods, logger)
+	var size int64
+	if err == nil {
+		var res fetched
+		resp.Items, res, err = store(b, request, keys, arc, filename)
+		size = res.n
+		if err != nil {
+			status = statusCode(err)
+			err = errors.WithStack(fmt.Errorf("request failed for %s: %v", request.URL, err))
+		}
+	}
+	duration := time.Since(startTime)
+	span.Set("http.response.status_code", status)
+	span.Set("http.response.body.size", size)
+	span.Set("retries", attempts-1)
+	if errors.Is(err, ErrNotModified) {
+		run.Metrics.Request(template, duration, 0, nil)
+		logger.Debug().
+			Int("attempt", attempts).
+			Int("status", status).
+			Dur("duration", duration).
+			Msgf("%s not modified", filename)
+		return resp, err
+	}
+	run.Metrics.Request(template, duration, size, err)
+	span.SetError(err)
 	if err != nil {
-		return res, err
+		logger.Error().Err(err).
+			Int("attempt", attempts).
+			Int("status", status).
+			Dur("duration", duration).
+			Msgf("%s failed", filename)
+		return Response{}, err
 	}
 
-	logger.Info().Msgf("%s complete", filename)
-	if err := arc.Add(filename, []byte(res.Raw)); err != nil {
-		return res, err
-	}
+	logger.Info().
+		Int("attempt", attempts).
+		Int("status", status).
+		Dur("duration", duration).
+		Int64("bytes", size).
+		Msgf("%s complete", filename)
 	logger.Debug().
 		TimeDiff("elapsed_time", time.Now(), startTime).
 		Msgf("done: %s", filename)
+	return resp, nil
+}
+
+// store adds the body to arc as filename while projecting its list items
+// to keys, if any, from the same stream.
+func store(b *stream, request requests.Request, keys []string, arc archive.Writer, filename string) (gjson.Result, fetched, error) {
+	if len(keys) == 0 {
+		err := arc.AddReader(filename, b)
+		res := b.close(err)
+		if err == nil {
+			err = res.err
+		}
+		return gjson.Result{}, res, err
+	}
+
+	pr, pw := io.Pipe()
+	var items gjson.Result
+	var perr error
+	projected := make(chan struct{})
+	go func() {
+		defer close(projected)
+		items, perr = jsonstream.Project(bufio.NewReader(pr), request.ListPath, keys)
+		io.Copy(io.Discard, pr)
+	}()
+	err := arc.AddReader(filename, io.TeeReader(b, pw))
+	pw.CloseWithError(err)
+	<-projected
+	res := b.close(err)
+	switch {
+	case err != nil:
+		return gjson.Result{}, res, err
+	case res.err != nil:
+		return gjson.Result{}, res, res.err
+	case perr != nil:
+		return gjson.Result{}, res, errors.WithStack(fmt.Errorf("cannot parse %s: %v", filename, perr))
+	}
+	return items, res, nil
+}
+
+// Carry stores body, the response to request in the baseline archive, in
+// arc unchanged, as FetchResult would have stored a fresh response, and
+// returns its list items reduced to keys.
+func Carry(
+	run *Run,
+	request requests.Request,
+	keys []string,
+	body io.ReadSeeker,
+	arc archive.Writer,
+) (gjson.Result, error) {
+	logger := requestLogger(run.Log, request)
+	filename := EntryName(request)
+
+	run.Progress.Begin(filename)
+	defer run.Progress.Done(filename)
+	span := startSpan(run, request, filename)
+	defer span.End()
+	span.Set("carried", true)
+
+	err := arc.AddReader(filename, body)
+	span.SetError(err)
+	if err != nil {
+		logger.Error().Err(err).Msgf("%s could not be carried forward", filename)
+		return gjson.Result{}, err
+	}
+	run.Metrics.Carry(metricsTemplate(request))
+	logger.Info().Bool("carried", true).Msgf("%s unchanged; carried forward from the baseline", filename)
+	return project(body, request, keys, filename)
+}
+
+// startSpan starts the trace span of the request stored as filename.
+func startSpan(run *Run, request requests.Request, filename string) *trace.Span {
+	span := run.Trace.StartRequest(filename)
+	span.Set("db_key", request.DBKey)
+	span.Set("url.path", request.URL)
+	if request.Fabric != "" {
+		span.Set("fabric", request.Fabric)
+	}
+	return span
+}
+
+// project returns the list items of body at the request's list_path reduced
+// to keys, or nothing when keys is empty.
+func project(body io.ReadSeeker, request requests.Request, keys []string, filename string) (gjson.Result, error) {
+	if len(keys) == 0 {
+		return gjson.Result{}, nil
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return gjson.Result{}, err
+	}
+	res, err := jsonstream.Project(bufio.NewReader(body), request.ListPath, keys)
+	if err != nil {
+		return gjson.Result{}, errors.WithStack(fmt.Errorf("cannot parse %s: %v", filename, err))
+	}
 	return res, nil
 }
 
+// normalizeTo writes data to w as canonical JSON, sorting list items by the
+// request's id_field. Responses that are not JSON are written unchanged.
+func normalizeTo(w io.Writer, data []byte, request requests.Request, logger log.Logger) error {
+	var buf bytes.Buffer
+	if err := jsonstream.Normalize(bytes.NewReader(data), &buf, request.ListPath, request.IDField); err != nil {
+		logger.Warn().Err(err).Msgf("cannot normalize %s; storing it as returned", request.URL)
+		_, err = w.Write(data)
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// requestLogger returns a logger carrying the fields that identify request:
+// a request_id unique within the process, its db_key, url and fabric.
+func requestLogger(logger log.Logger, request requests.Request) log.Logger {
+	ctx := logger.With().
+		Uint64("request_id", requestID.Add(1)).
+		Str("db_key", request.DBKey).
+		Str("url", request.URL)
+	if request.Fabric != "" {
+		ctx = ctx.Str("fabric", request.Fabric)
+	}
+	return ctx.Logger()
+}
+
+// metricsTemplate returns the label request is measured under: its db_key
+// template, falling back to the URL for single endpoint collections.
+func metricsTemplate(request requests.Request) string {
+	switch {
+	case request.Template != "":
+		return request.Template
+	case request.DBKey != "":
+		return request.DBKey
+	}
+	return request.URL
+}
+
 // Fetch fetches data via API and writes it to the provided archive.
 func Fetch(
+	run *Run,
 	client ndfc.Client,
 	request requests.Request,
 	arc archive.Writer,
 	cfg *config.Config,
 ) error {
-	_, err := FetchResult(client, request, arc, cfg)
+	_, err := FetchResult(run, client, request, nil, arc, cfg)
 	return err
 }
 
+// EntryName returns the archive entry name for a resolved request.
+// The db_key is used when available for human-readable names, e.g.
+// db_key "inventory/switches" -> "inventory.switches.json".
+// Falls back to URL-based naming for requests without a db_key.
+func EntryName(request requests.Request) string {
+	if filename := dbKeyToFilename(request.DBKey); filename != "" {
+		return filename
+	}
+	return urlToFilename(request.URL)
+}
+
 // dbKeyToFilename converts a db_key to a filename.
 // Example: "inventory/switches" -> "inventory.switches.json"
 // Returns empty string if dbKey is empty.
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/metrics"
+	"ndfc-collector/pkg/progress"
+	"ndfc-collector/pkg/trace"
+)
+
+// Run is the state of one collection run that its requests report to, so
+// that runs in the same process are measured and logged separately.
+type Run struct {
+	Log      log.Logger
+	Metrics  *metrics.Metrics
+	Trace    *trace.Tracer     // nil unless tracing
+	Progress *progress.Tracker // nil unless the progress display is shown
+}
+
+// NewRun returns a run logging to logger, with fresh metrics.
+func NewRun(logger log.Logger) *Run {
+	return &Run{Log: logger, Metrics: metrics.New()}
+}
@@ -20,6 +20,7 @@ package config
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
@@ -34,18 +35,61 @@ const defaultOutputFile = "ndfc-collection-data.zip"
 
 // Config holds all settings for the NDFC collector.
 type Config struct {
-	URL               string            `yaml:"url"`
-	Output            string            `yaml:"output"`
-	Username          string            `yaml:"username"`
-	Password          string            `yaml:"password"`
-	RequestRetryCount int               `yaml:"request_retry_count"`
-	RetryDelay        int               `yaml:"retry_delay"`
-	BatchSize         int               `yaml:"batch_size"`
-	PageSize          int               `yaml:"page_size"`
-	Confirm           bool              `yaml:"confirm"`
-	Verbose           bool              `yaml:"verbose"`
-	Endpoint          string            `yaml:"endpoint"`
-	Query             map[string]string `yaml:"query"`
+	URL               string                    `yaml:"url"`
+	ControllerVersion string                    `yaml:"controller_version"`
+	Output            string                    `yaml:"output"`
+	Format            string                    `yaml:"format"`
+	VolumeSize        string                    `yaml:"volume_size"`
+	Normalize         bool                      `yaml:"normalize"`
+	Baseline          string                    `yaml:"baseline"`
+	Cache             bool                      `yaml:"cache"`
+	CacheDir          string                    `yaml:"cache_dir"`
+	CacheMaxAge       int                       `yaml:"cache_max_age"`
+	DBOutput          string                    `yaml:"db_output"`
+	SQLiteOutput      string                    `yaml:"sqlite_output"`
+	Checks            string                    `yaml:"checks"`
+	CheckOutput       string                    `yaml:"check_output"`
+	ReportOutput      string                    `yaml:"report_output"`
+	MetricsListen     string                    `yaml:"metrics_listen"`
+	TraceOutput       string                    `yaml:"trace_output"`
+	TraceEndpoint     string                    `yaml:"trace_endpoint"`
+	Username          string                    `yaml:"username"`
+	Password          string                    `yaml:"password"`
+	RequestRetryCount int                       `yaml:"request_retry_count"`
+	RetryDelay        int                       `yaml:"retry_delay"`
+	BatchSize         int                       `yaml:"batch_size"`
+	PageSize          int                       `yaml:"page_size"`
+	RateLimit         float64                   `yaml:"rate_limit"`
+	RateBurst         int                       `yaml:"rate_burst"`
+	AdaptiveRate      bool                      `yaml:"adaptive_rate"`
+	Compression       string                    `yaml:"compression"`
+	CompressionLevel  string                    `yaml:"compression_level"`
+	EncryptRecipients []string                  `yaml:"encrypt_recipients"`
+	EncryptPassphrase string                    `yaml:"encrypt_passphrase"`
+	SignKey           string                    `yaml:"sign_key"`
+	Redact            bool                      `yaml:"redact"`
+	RedactRules       string                    `yaml:"redact_rules"`
+	RedactKey         string                    `yaml:"redact_key"`
+	RedactMappingFile string                    `yaml:"redact_mapping_file"`
+	Confirm           bool                      `yaml:"confirm"`
+	Verbose           bool                      `yaml:"verbose"`
+	Quiet             bool                      `yaml:"quiet"`
+	LogFormat         string                    `yaml:"log_format"`
+	LogLevel          string                    `yaml:"log_level"`
+	Endpoint          string                    `yaml:"endpoint"`
+	Schedule          string                    `yaml:"schedule"`
+	ArchiveDir        string                    `yaml:"archive_dir"`
+	KeepDaily         int                       `yaml:"keep_daily"`
+	KeepWeekly        int                       `yaml:"keep_weekly"`
+	Incremental       bool                      `yaml:"incremental"`
+	FullEvery         int                       `yaml:"full_every"`
+	Listen            string                    `yaml:"listen"`
+	APIToken          string                    `yaml:"api_token"`
+	MaxJobs           int                       `yaml:"max_jobs"`
+	MaxControllerJobs int                       `yaml:"max_controller_jobs"`
+	MaxQueuedJobs     int                       `yaml:"max_queued_jobs"`
+	Profiles          map[string]map[string]any `yaml:"profiles"`
+	Query             map[string]string         `yaml:"query"`
 }
 
 // New returns a Config with default values.
@@ -54,8 +98,17 @@ func New() Config {
 		Output:            defaultOutputFile,
 		RequestRetryCount: 3,
 		RetryDelay:        10,
+		CacheMaxAge:       7,
 		BatchSize:         7,
 		PageSize:          1000,
+		RateBurst:         7,
+		Compression:       "deflate",
+		CompressionLevel:  "default",
+		LogFormat:         "console",
+		MaxJobs:           2,
+		MaxControllerJobs: 1,
+		MaxQueuedJobs:     20,
+		FullEvery:         7,
 		Endpoint:          "all",
 	}
 }
@@ -76,6 +129,21 @@ func ParseConfig(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// Apply sets the settings named by their YAML keys in overrides, e.g. from
+// a profile. Unknown keys are an error.
+func (c *Config) Apply(overrides map[string]any) error {
+	data, err := yaml.Marshal(overrides)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return errors.WithStack(fmt.Errorf("invalid settings: %w", err))
+	}
+	return nil
+}
+
 // NormalizeAndPrompt fills missing required values interactively and normalizes inputs.
 func (c *Config) NormalizeAndPrompt() error {
 	if c.URL == "" {
@@ -104,6 +172,11 @@ func input(prompt string) string {
 	return strings.Trim(s, "\r\n")
 }
 
+// PromptPassword reads a password from the terminal without echoing it.
+func PromptPassword(prompt string) string {
+	return inputPassword(prompt)
+}
+
 func inputPassword(prompt string) string {
 	fmt.Print(prompt + " ")
 	pwd, _ := term.ReadPassword(int(syscall.Stdin))
@@ -37,9 +37,17 @@ func TestNew_DefaultNumerics(t *testing.T) {
 	assert.Equal(t, 10, cfg.RetryDelay)
 	assert.Equal(t, 7, cfg.BatchSize)
 	assert.Equal(t, 1000, cfg.PageSize)
+	assert.Equal(t, 0.0, cfg.RateLimit)
+	assert.Equal(t, 7, cfg.RateBurst)
 	assert.Equal(t, "all", cfg.Endpoint)
 }
 
+func TestNew_DefaultCompression(t *testing.T) {
+	cfg := New()
+	assert.Equal(t, "deflate", cfg.Compression)
+	assert.Equal(t, "default", cfg.CompressionLevel)
+}
+
 func TestParseConfig_PreservesDefaultOutputWhenAbsent(t *testing.T) {
 	data := "url: https://ndfc.example.com\nusername: admin\n"
 	path := filepath.Join(t.TempDir(), "config.yaml")
@@ -66,6 +74,25 @@ func TestParseConfig_MissingFile(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestApply(t *testing.T) {
+	cfg := New()
+	require.NoError(t, cfg.Apply(map[string]any{
+		"page_size": float64(500), // as decoded from JSON
+		"normalize": true,
+		"endpoint":  "/api/v1/fabrics",
+		"query":     map[string]any{"filter": "x"},
+	}))
+	assert.Equal(t, 500, cfg.PageSize)
+	assert.True(t, cfg.Normalize)
+	assert.Equal(t, "/api/v1/fabrics", cfg.Endpoint)
+	assert.Equal(t, map[string]string{"filter": "x"}, cfg.Query)
+	assert.Equal(t, 7, cfg.BatchSize, "other settings are unchanged")
+
+	assert.Error(t, cfg.Apply(map[string]any{"page_sise": 1}))
+	assert.Error(t, cfg.Apply(map[string]any{"page_size": "many"}))
+	assert.NoError(t, cfg.Apply(nil))
+}
+
 func TestNormalizeURL_StripsHTTPS(t *testing.T) {
 	assert.Equal(t, "ndfc.example.com", normalizeURL("https://ndfc.example.com"))
 }
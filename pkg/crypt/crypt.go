@@ -0,0 +1,141 @@
+// Package crypt encrypts collection archives before they leave the network.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crypt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Extension is appended to the names of encrypted archives.
+const Extension = ".enc"
+
+// ageMagic is the first line of every age-encrypted file.
+const ageMagic = "age-encryption.org/"
+
+// scryptStanza starts the header stanza of passphrase encrypted files.
+const scryptStanza = "-> scrypt "
+
+// NewWriter returns a WriteCloser that encrypts everything written to it
+// and writes the ciphertext to w. Data is encrypted to the given age X25519
+// recipients (public keys starting with "age1") when any are provided,
+// otherwise to an age scrypt recipient for passphrase. Close must be called
+// to flush the final chunk; it does not close w.
+func NewWriter(w io.Writer, recipients []string, passphrase string) (io.WriteCloser, error) {
+	if len(recipients) > 0 {
+		rcpts := make([]age.Recipient, 0, len(recipients))
+		for _, s := range recipients {
+			r, err := age.ParseX25519Recipient(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("invalid recipient %q: %w", s, err)
+			}
+			rcpts = append(rcpts, r)
+		}
+		return age.Encrypt(w, rcpts...)
+	}
+	if passphrase == "" {
+		return nil, errors.New("a recipient or passphrase is required for encryption")
+	}
+	r, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return age.Encrypt(w, r)
+}
+
+// NewReader returns a Reader that decrypts r. Whether r was encrypted with
+// a passphrase is detected from its age header: passphrase files are
+// decrypted with passphrase, others with the identities (private keys) in
+// identityFiles.
+func NewReader(r io.Reader, identityFiles []string, passphrase string) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	isPass, err := passphraseHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if isPass {
+		if passphrase == "" {
+			return nil, errors.New("archive is passphrase encrypted; a passphrase is required")
+		}
+		id, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		r, err := age.Decrypt(br, id)
+		if err != nil {
+			return nil, errors.New("decryption failed: wrong passphrase or corrupted archive")
+		}
+		return r, nil
+	}
+	if len(identityFiles) == 0 {
+		return nil, errors.New("archive is encrypted to a public key; an identity file is required")
+	}
+	var ids []age.Identity
+	for _, path := range identityFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := age.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing identity file %s: %w", path, err)
+		}
+		ids = append(ids, parsed...)
+	}
+	return age.Decrypt(br, ids...)
+}
+
+// IsPassphraseEncrypted reports whether the file at path was encrypted with
+// a passphrase rather than to age recipients.
+func IsPassphraseEncrypted(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	isPass, err := passphraseHeader(bufio.NewReader(f))
+	if err != nil {
+		return false, nil
+	}
+	return isPass, nil
+}
+
+// passphraseHeader checks that br starts with an age header and reports
+// whether its first recipient stanza is an scrypt (passphrase) one, which
+// age requires to be the only stanza.
+func passphraseHeader(br *bufio.Reader) (bool, error) {
+	head, err := br.Peek(len(ageMagic) + len("v1\n") + len(scryptStanza))
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("reading header: %w", err)
+	}
+	if !bytes.HasPrefix(head, []byte(ageMagic)) {
+		return false, errors.New("not an encrypted archive")
+	}
+	_, stanza, _ := bytes.Cut(head, []byte("\n"))
+	return bytes.HasPrefix(stanza, []byte(scryptStanza)), nil
+}
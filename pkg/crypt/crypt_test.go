@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encrypt(t *testing.T, plain []byte, recipients []string, passphrase string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, recipients, passphrase)
+	require.NoError(t, err)
+	_, err = w.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decrypt(t *testing.T, data []byte, identities []string, passphrase string) ([]byte, error) {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), identities, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestPassphrase_RoundTrip(t *testing.T) {
+	// age encrypts in 64 KiB chunks; cover an empty, a partial and a
+	// multi-chunk payload.
+	for _, size := range []int{0, 1, 3*64*1024 + 1} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+		data := encrypt(t, plain, nil, "s3cret")
+		assert.True(t, bytes.HasPrefix(data, []byte(ageMagic)))
+
+		got, err := decrypt(t, data, nil, "s3cret")
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, plain, got, "size %d", size)
+	}
+}
+
+func TestPassphrase_WrongPassphrase(t *testing.T) {
+	data := encrypt(t, []byte("secret config"), nil, "right")
+	_, err := decrypt(t, data, nil, "wrong")
+	assert.Error(t, err)
+	_, err = decrypt(t, data, nil, "")
+	assert.Error(t, err, "a passphrase is required")
+}
+
+func TestIsPassphraseEncrypted(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	pass := filepath.Join(dir, "pass.enc")
+	require.NoError(t, os.WriteFile(pass, encrypt(t, []byte("x"), nil, "pw"), 0o600))
+	key := filepath.Join(dir, "key.enc")
+	require.NoError(t, os.WriteFile(key, encrypt(t, []byte("x"), []string{id.Recipient().String()}, ""), 0o600))
+
+	isPass, err := IsPassphraseEncrypted(pass)
+	require.NoError(t, err)
+	assert.True(t, isPass)
+	isPass, err = IsPassphraseEncrypted(key)
+	require.NoError(t, err)
+	assert.False(t, isPass)
+}
+
+func TestPassphrase_Tampered(t *testing.T) {
+	data := encrypt(t, []byte("secret config"), nil, "pw")
+	data[len(data)-1] ^= 0xff
+	_, err := decrypt(t, data, nil, "pw")
+	assert.Error(t, err)
+}
+
+func TestRecipient_RoundTrip(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	idFile := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(idFile, []byte(id.String()+"\n"), 0o600))
+
+	plain := []byte(`{"fabrics":[]}`)
+	data := encrypt(t, plain, []string{id.Recipient().String()}, "")
+
+	got, err := decrypt(t, data, []string{idFile}, "")
+	require.NoError(t, err)
+	assert.Equal(t, plain, got)
+
+	_, err = decrypt(t, data, nil, "")
+	assert.Error(t, err, "an identity is required")
+}
+
+func TestNewWriter_Errors(t *testing.T) {
+	_, err := NewWriter(io.Discard, nil, "")
+	assert.Error(t, err)
+	_, err = NewWriter(io.Discard, []string{"not-a-key"}, "")
+	assert.Error(t, err)
+}
+
+func TestNewReader_NotEncrypted(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte("PK\x03\x04 plain zip data")), nil, "pw")
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/tidwall/buntdb"
+)
+
+// batchSize is the number of records committed per buntDB transaction.
+const batchSize = 1000
+
+// BuntDB writes records to a buntDB file using the db_key scheme shared
+// with other tools: each item is stored as JSON under "db_key:id", e.g.
+// inventory/switches:FDO12345678. Items without an id, such as single
+// object responses, use their position within the response instead.
+type BuntDB struct {
+	db  *buntdb.DB
+	tx  *buntdb.Tx
+	n   int
+	pos map[string]int // next position per db_key
+}
+
+// NewBuntDB creates the buntDB file at path, replacing any existing file.
+func NewBuntDB(path string) (*BuntDB, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("removing existing database: %w", err)
+	}
+	db, err := buntdb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	// Close syncs the file once at the end; syncing every batch only slows
+	// down large exports.
+	var cfg buntdb.Config
+	if err := db.ReadConfig(&cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+	cfg.SyncPolicy = buntdb.Never
+	if err := db.SetConfig(cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BuntDB{db: db, pos: map[string]int{}}, nil
+}
+
+// key returns the key rec is stored under. pos is the position of the
+// item within its db_key and is only used when rec has no id.
+func key(rec Record, pos int) string {
+	if rec.ID != "" {
+		return rec.DBKey + ":" + rec.ID
+	}
+	return rec.DBKey + ":" + strconv.Itoa(pos)
+}
+
+// Write stores a single record, replacing any earlier record with the
+// same key.
+func (b *BuntDB) Write(rec Record) error {
+	if b.tx == nil {
+		tx, err := b.db.Begin(true)
+		if err != nil {
+			return err
+		}
+		b.tx = tx
+	}
+	pos := b.pos[rec.DBKey]
+	b.pos[rec.DBKey] = pos + 1
+	if _, _, err := b.tx.Set(key(rec, pos), string(rec.Item), nil); err != nil {
+		return err
+	}
+	b.n++
+	if b.n%batchSize == 0 {
+		return b.commit()
+	}
+	return nil
+}
+
+func (b *BuntDB) commit() error {
+	if b.tx == nil {
+		return nil
+	}
+	tx := b.tx
+	b.tx = nil
+	return tx.Commit()
+}
+
+// Close commits pending records and closes the database.
+func (b *BuntDB) Close() error {
+	err := b.commit()
+	if cerr := b.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Abort rolls back pending records and closes the database. Batches
+// committed earlier stay in the file.
+func (b *BuntDB) Abort() error {
+	var err error
+	if b.tx != nil {
+		err = b.tx.Rollback()
+		b.tx = nil
+	}
+	if cerr := b.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
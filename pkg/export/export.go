@@ -0,0 +1,145 @@
+// Package export converts collection archives into formats for ingestion
+// by other tools.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/jsonstream"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/tidwall/gjson"
+)
+
+// Record is a single list item from a collected response.
+type Record struct {
+	DBKey    string            `json:"db_key"`        // resolved db_key, or the URL when the request has none
+	Template string            `json:"-"`             // unresolved db_key, e.g. fabrics/{fabricName}/vrfs
+	ID       string            `json:"id,omitempty"`  // value of the request's id_field
+	Ctx      map[string]string `json:"ctx,omitempty"` // placeholder values, e.g. the fabric name
+	Item     json.RawMessage   `json:"item"`
+}
+
+// Exporter writes records to an output format. Close finishes the output;
+// Abort gives up on it after an error, discarding what it can.
+type Exporter interface {
+	Write(Record) error
+	Close() error
+	Abort() error
+}
+
+// Results returns the request results recorded in an archive's manifest.
+func Results(r archive.Reader) ([]requests.Result, error) {
+	data, err := archive.ReadFile(r, archive.ManifestName)
+	if err != nil {
+		return nil, err
+	}
+	var m struct {
+		Meta map[string]json.RawMessage `json:"meta"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	raw, ok := m.Meta[requests.MetaKey]
+	if !ok {
+		return nil, errors.New("archive has no request results; it was collected by an older version")
+	}
+	var results []requests.Result
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return nil, fmt.Errorf("invalid request results: %w", err)
+	}
+	return results, nil
+}
+
+// Walk calls fn with a Record for every list item of every successfully
+// collected response in r. catalog supplies the list_path and id_field of
+// each request; requests not in the catalog, such as single endpoint
+// collections, are exported using the whole response.
+func Walk(r archive.Reader, catalog []requests.Request, fn func(Record) error) error {
+	results, err := Results(r)
+	if err != nil {
+		return err
+	}
+	templates := make(map[string]requests.Request, len(catalog))
+	for _, req := range catalog {
+		templates[req.URL] = req
+	}
+	byEntry := make(map[string]requests.Result, len(results))
+	for _, res := range results {
+		if res.Error == "" {
+			byEntry[res.Entry] = res
+		}
+	}
+
+	return r.Walk(func(name string, rd io.Reader) error {
+		res, ok := byEntry[name]
+		if !ok {
+			return nil
+		}
+		req := templates[res.Template]
+		dbKey, template := res.DBKey, req.DBKey
+		if dbKey == "" {
+			dbKey = res.URL
+		}
+		if template == "" {
+			template = dbKey
+		}
+		err := jsonstream.Items(bufio.NewReader(rd), req.ListPath, func(item json.RawMessage) error {
+			rec := Record{DBKey: dbKey, Template: template, Ctx: res.Ctx, Item: item}
+			if req.IDField != "" {
+				if id := gjson.GetBytes(item, req.IDField); id.Exists() {
+					rec.ID = id.String()
+				}
+			}
+			return fn(rec)
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// Archive exports every record in the archive at path with exp, closing
+// exp afterwards, or aborting it if the export fails. It returns the number
+// of records written.
+func Archive(path string, catalog []requests.Request, exp Exporter) (int, error) {
+	r, err := archive.Open(path)
+	if err != nil {
+		exp.Abort()
+		return 0, err
+	}
+	defer r.Close()
+	n := 0
+	err = Walk(r, catalog, func(rec Record) error {
+		n++
+		return exp.Write(rec)
+	})
+	if err != nil {
+		exp.Abort()
+		return n, err
+	}
+	return n, exp.Close()
+}
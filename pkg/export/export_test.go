@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/buntdb"
+)
+
+var testCatalog = []requests.Request{
+	{URL: "/fabrics", DBKey: "manage/fabrics", ListPath: "fabrics", IDField: "fabricName"},
+	{URL: "/fabrics/{fabricName}/vrfs", DBKey: "fabrics/{fabricName}/vrfs", ListPath: "@this", IDField: "vrfId"},
+}
+
+// testArchive writes a small collection archive and returns its path.
+func testArchive(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.zip")
+	arc, err := archive.NewWriter(path)
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("manage.fabrics.json", []byte(`{"fabrics":[{"fabricName":"f1"},{"fabricName":"f2"}]}`)))
+	require.NoError(t, arc.Add("fabrics.f1.vrfs.json", []byte(`[{"vrfId":50001,"vrfName":"blue"},{"vrfName":"no-id"}]`)))
+	require.NoError(t, arc.Add("single.json", []byte(`{"version":"12.2"}`)))
+	arc.SetMeta(requests.MetaKey, []requests.Result{
+		{Entry: "manage.fabrics.json", URL: "/fabrics", Template: "/fabrics", DBKey: "manage/fabrics"},
+		{
+			Entry: "fabrics.f1.vrfs.json", URL: "/fabrics/f1/vrfs", Template: "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/f1/vrfs", Ctx: map[string]string{"fabricName": "f1"},
+		},
+		{
+			Entry: "fabrics.f2.vrfs.json", URL: "/fabrics/f2/vrfs", Template: "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/f2/vrfs", Error: "request failed",
+		},
+		{Entry: "single.json", URL: "/version", Template: "/version"},
+	})
+	require.NoError(t, arc.Close())
+	return path
+}
+
+func TestWalk(t *testing.T) {
+	r, err := archive.Open(testArchive(t))
+	require.NoError(t, err)
+	defer r.Close()
+
+	var got []Record
+	require.NoError(t, Walk(r, testCatalog, func(rec Record) error {
+		got = append(got, rec)
+		return nil
+	}))
+	assert.Equal(t, []Record{
+		{DBKey: "manage/fabrics", Template: "manage/fabrics", ID: "f1", Item: json.RawMessage(`{"fabricName":"f1"}`)},
+		{DBKey: "manage/fabrics", Template: "manage/fabrics", ID: "f2", Item: json.RawMessage(`{"fabricName":"f2"}`)},
+		{
+			DBKey: "fabrics/f1/vrfs", Template: "fabrics/{fabricName}/vrfs", ID: "50001",
+			Ctx: map[string]string{"fabricName": "f1"}, Item: json.RawMessage(`{"vrfId":50001,"vrfName":"blue"}`),
+		},
+		{
+			DBKey: "fabrics/f1/vrfs", Template: "fabrics/{fabricName}/vrfs",
+			Ctx: map[string]string{"fabricName": "f1"}, Item: json.RawMessage(`{"vrfName":"no-id"}`),
+		},
+		{DBKey: "/version", Template: "/version", Item: json.RawMessage(`{"version":"12.2"}`)},
+	}, got)
+}
+
+func TestWalk_NoResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.zip")
+	arc, err := archive.NewWriter(path)
+	require.NoError(t, err)
+	require.NoError(t, arc.Close())
+	r, err := archive.Open(path)
+	require.NoError(t, err)
+	defer r.Close()
+	assert.ErrorContains(t, Walk(r, testCatalog, func(Record) error { return nil }), "older version")
+}
+
+func TestNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Archive(testArchive(t), testCatalog, NewNDJSON(&buf))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, `{"db_key":"fabrics/f1/vrfs","id":"50001","ctx":{"fabricName":"f1"},"item":{"vrfId":50001,"vrfName":"blue"}}`, lines[2])
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), line)
+	}
+}
+
+func TestBuntDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.db")
+	require.NoError(t, os.WriteFile(path, []byte("stale"), 0o644))
+	exp, err := NewBuntDB(path)
+	require.NoError(t, err)
+	n, err := Archive(testArchive(t), testCatalog, exp)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	db, err := buntdb.Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	got := map[string]string{}
+	require.NoError(t, db.View(func(tx *buntdb.Tx) error {
+		return tx.Ascend("", func(key, value string) bool {
+			got[key] = value
+			return true
+		})
+	}))
+	assert.Equal(t, map[string]string{
+		"manage/fabrics:f1":     `{"fabricName":"f1"}`,
+		"manage/fabrics:f2":     `{"fabricName":"f2"}`,
+		"fabrics/f1/vrfs:50001": `{"vrfId":50001,"vrfName":"blue"}`,
+		"fabrics/f1/vrfs:1":     `{"vrfName":"no-id"}`,
+		"/version:0":            `{"version":"12.2"}`,
+	}, got)
+}
+
+func TestTableName(t *testing.T) {
+	assert.Equal(t, "inventory_switches", tableName("inventory/switches"))
+	assert.Equal(t, "fabrics_vrfs", tableName("fabrics/{fabricName}/vrfs"))
+	assert.Equal(t, "lan_fabric_control_fabrics_msd", tableName("lan-fabric/control/fabrics/msd"))
+	assert.Equal(t, "version", tableName("/version"))
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.sqlite")
+	exp, err := NewSQLite(path)
+	require.NoError(t, err)
+	require.NoError(t, exp.Write(Record{
+		DBKey: "fabrics/f1/switches", Template: "fabrics/{fabricName}/switches", ID: "s1",
+		Ctx:  map[string]string{"fabricName": "f1"},
+		Item: json.RawMessage(`{"serial":"s1","fabricName":"other","ports":48,"mgmt":{"ip":"10.0.0.1"},"vlans":[10,20]}`),
+	}))
+	require.NoError(t, exp.Write(Record{
+		DBKey: "fabrics/f2/switches", Template: "fabrics/{fabricName}/switches",
+		Ctx:  map[string]string{"fabricName": "f2"},
+		Item: json.RawMessage(`{"serial":"s2","uptime":1.5}`),
+	}))
+	n, err := Archive(testArchive(t), testCatalog, exp)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT _db_key, _id, fabricName, serial, ports, mgmt_ip, vlans, uptime FROM fabrics_switches ORDER BY serial`)
+	require.NoError(t, err)
+	defer rows.Close()
+	type row struct {
+		DBKey, FabricName, Serial string
+		ID, MgmtIP, VLANs         sql.NullString
+		Ports                     sql.NullInt64
+		Uptime                    sql.NullFloat64
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.DBKey, &r.ID, &r.FabricName, &r.Serial, &r.Ports, &r.MgmtIP, &r.VLANs, &r.Uptime))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []row{
+		{
+			DBKey: "fabrics/f1/switches", ID: sql.NullString{String: "s1", Valid: true}, FabricName: "f1", Serial: "s1",
+			Ports: sql.NullInt64{Int64: 48, Valid: true}, MgmtIP: sql.NullString{String: "10.0.0.1", Valid: true},
+			VLANs: sql.NullString{String: "[10,20]", Valid: true},
+		},
+		{DBKey: "fabrics/f2/switches", FabricName: "f2", Serial: "s2", Uptime: sql.NullFloat64{Float64: 1.5, Valid: true}},
+	}, got)
+
+	var raw string
+	require.NoError(t, db.QueryRow(`SELECT _raw FROM fabrics_vrfs WHERE vrfId = 50001`).Scan(&raw))
+	assert.Equal(t, `{"vrfId":50001,"vrfName":"blue"}`, raw)
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM manage_fabrics`).Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestSQLite_ColumnCollisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.sqlite")
+	exp, err := NewSQLite(path)
+	require.NoError(t, err)
+	for _, item := range []string{
+		`{"mgmt":{"ip":"10.0.0.1"}}`,
+		`{"mgmt":{"ip":"10.0.0.2"},"mgmt_ip":"10.0.0.3","MGMT_IP":"10.0.0.4"}`,
+	} {
+		require.NoError(t, exp.Write(Record{DBKey: "switches", Template: "switches", Item: json.RawMessage(item)}))
+	}
+	require.NoError(t, exp.Close())
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	rows, err := db.Query(`SELECT mgmt_ip, mgmt_ip_2, MGMT_IP_3 FROM switches ORDER BY rowid`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var got [][3]sql.NullString
+	for rows.Next() {
+		var r [3]sql.NullString
+		require.NoError(t, rows.Scan(&r[0], &r[1], &r[2]))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+	// The nested field claimed mgmt_ip first and keeps it; the top-level
+	// fields get numbered columns, in key order.
+	assert.Equal(t, [][3]sql.NullString{
+		{{String: "10.0.0.1", Valid: true}, {}, {}},
+		{{String: "10.0.0.2", Valid: true}, {String: "10.0.0.4", Valid: true}, {String: "10.0.0.3", Valid: true}},
+	}, got)
+}
+
+func TestSQLite_RollsBackOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.sqlite")
+	exp, err := NewSQLite(path)
+	require.NoError(t, err)
+	require.NoError(t, exp.Write(Record{DBKey: "switches", Template: "switches", Item: json.RawMessage(`{"serial":"s1"}`)}))
+	_, err = Archive(filepath.Join(t.TempDir(), "missing.zip"), testCatalog, exp)
+	require.Error(t, err)
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'switches'`).Scan(&count))
+	assert.Zero(t, count, "nothing is committed")
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// NDJSON writes records as newline-delimited JSON, one object per line.
+type NDJSON struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewNDJSON returns an exporter writing to w. Close flushes the output but
+// does not close w.
+func NewNDJSON(w io.Writer) *NDJSON {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &NDJSON{w: bw, enc: enc}
+}
+
+// Write writes a single record.
+func (n *NDJSON) Write(rec Record) error {
+	return n.enc.Encode(rec)
+}
+
+// Close flushes buffered records.
+func (n *NDJSON) Close() error {
+	return n.w.Flush()
+}
+
+// Abort drops buffered records. Records already flushed stay in w.
+func (n *NDJSON) Abort() error {
+	n.w.Reset(io.Discard)
+	return nil
+}
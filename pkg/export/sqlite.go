@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// SQLite writes records to a SQLite database with one table per db_key
+// template, e.g. fabrics_vrfs for fabrics/{fabricName}/vrfs. Every table
+// has these columns:
+//
+//   - _db_key: the resolved db_key, e.g. fabrics/site1/vrfs
+//   - _id: the value of the request's id_field, if any
+//   - _raw: the item as JSON
+//   - one column per placeholder, e.g. fabricName
+//   - one column per scalar field of the item, with nested object fields
+//     joined by underscores, e.g. fabric_name. Lists are stored as JSON.
+//
+// Columns are added as new fields appear. Column names are
+// case-insensitive in SQLite, and a nested field such as fabric.name
+// flattens to the same name as a top-level fabric_name, so a field whose
+// name is already taken by another field or a placeholder gets a numbered
+// column instead, e.g. fabric_name_2. Shallower fields claim names first,
+// and a field keeps its column for the whole table.
+//
+// Records are written in a single transaction, committed by Close and
+// rolled back by Abort.
+type SQLite struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	tables map[string]*sqlTable
+}
+
+type sqlTable struct {
+	name    string
+	columns map[string]bool   // lower-case column names
+	fields  map[string]string // field path -> column name
+}
+
+// column returns the column of the field at path, adding a column named
+// after name, or a numbered variant of it, on first use.
+func (s *SQLite) column(t *sqlTable, path, name string) (string, error) {
+	if col, ok := t.fields[path]; ok {
+		return col, nil
+	}
+	col := name
+	for i := 2; t.columns[strings.ToLower(col)]; i++ {
+		col = fmt.Sprintf("%s_%d", name, i)
+	}
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", quote(t.name), quote(col))
+	if _, err := s.tx.Exec(stmt); err != nil {
+		return "", fmt.Errorf("adding column %s to %s: %w", col, t.name, err)
+	}
+	t.columns[strings.ToLower(col)] = true
+	t.fields[path] = col
+	return col, nil
+}
+
+// NewSQLite creates the SQLite database at path, replacing any existing file.
+func NewSQLite(path string) (*SQLite, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("removing existing database: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	return &SQLite{db: db, tx: tx, tables: map[string]*sqlTable{}}, nil
+}
+
+var (
+	placeholderSegment = regexp.MustCompile(`^\{[^}]*\}$`)
+	invalidIdentChars  = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// tableName derives a table name from a db_key template by dropping
+// placeholder segments, e.g. fabrics/{fabricName}/vrfs -> fabrics_vrfs.
+func tableName(template string) string {
+	var parts []string
+	for _, seg := range strings.Split(template, "/") {
+		if seg == "" || placeholderSegment.MatchString(seg) {
+			continue
+		}
+		parts = append(parts, invalidIdentChars.ReplaceAllString(seg, "_"))
+	}
+	return strings.Join(parts, "_")
+}
+
+// quote returns name as an SQL identifier.
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// table returns the table for template, creating it on first use.
+func (s *SQLite) table(template string) (*sqlTable, error) {
+	name := tableName(template)
+	if t, ok := s.tables[name]; ok {
+		return t, nil
+	}
+	t := &sqlTable{
+		name:    name,
+		columns: map[string]bool{"_db_key": true, "_id": true, "_raw": true},
+		fields:  map[string]string{},
+	}
+	stmt := fmt.Sprintf(`CREATE TABLE %s ("_db_key" TEXT, "_id" TEXT, "_raw" TEXT)`, quote(name))
+	if _, err := s.tx.Exec(stmt); err != nil {
+		return nil, fmt.Errorf("creating table %s: %w", name, err)
+	}
+	s.tables[name] = t
+	return t, nil
+}
+
+// Write inserts a single record.
+func (s *SQLite) Write(rec Record) error {
+	t, err := s.table(rec.Template)
+	if err != nil {
+		return err
+	}
+
+	var id any
+	if rec.ID != "" {
+		id = rec.ID
+	}
+	cols := []string{"_db_key", "_id", "_raw"}
+	vals := []any{rec.DBKey, id, string(rec.Item)}
+	add := func(path, name string, val any) error {
+		col, err := s.column(t, path, name)
+		if err != nil {
+			return err
+		}
+		cols = append(cols, col)
+		vals = append(vals, val)
+		return nil
+	}
+
+	ctxKeys := make([]string, 0, len(rec.Ctx))
+	for k := range rec.Ctx {
+		ctxKeys = append(ctxKeys, k)
+	}
+	sort.Strings(ctxKeys)
+	for _, k := range ctxKeys {
+		// Placeholder paths cannot clash with field paths, which never
+		// start with a brace.
+		if err := add("{"+k+"}", k, rec.Ctx[k]); err != nil {
+			return err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(rec.Item))
+	dec.UseNumber()
+	var item any
+	if err := dec.Decode(&item); err != nil {
+		return fmt.Errorf("decoding item: %w", err)
+	}
+	if obj, ok := item.(map[string]any); ok {
+		var fields []field
+		flatten(nil, obj, &fields)
+		sort.Slice(fields, func(i, j int) bool {
+			a, b := fields[i].path, fields[j].path
+			if len(a) != len(b) {
+				return len(a) < len(b)
+			}
+			return slices.Compare(a, b) < 0
+		})
+		for _, f := range fields {
+			if err := add(pathKey(f.path), strings.Join(f.path, "_"), f.value); err != nil {
+				return err
+			}
+		}
+	}
+
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = quote(col)
+	}
+	stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?%s)",
+		quote(t.name), strings.Join(quoted, ", "), strings.Repeat(", ?", len(cols)-1))
+	if _, err := s.tx.Exec(stmt, vals...); err != nil {
+		return fmt.Errorf("inserting into %s: %w", t.name, err)
+	}
+	return nil
+}
+
+// field is a scalar or list value of an item at path, the object keys
+// leading to it.
+type field struct {
+	path  []string
+	value any
+}
+
+// pathKey joins path with a separator that cannot occur in JSON keys read
+// from NDFC, so fabric.name and a key named "fabric.name" stay distinct.
+func pathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// flatten adds the scalar fields of obj, nested below prefix, to fields.
+// Lists are encoded as JSON text.
+func flatten(prefix []string, obj map[string]any, fields *[]field) {
+	for k, v := range obj {
+		path := append(slices.Clip(prefix), k)
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(path, v, fields)
+		case []any:
+			data, _ := json.Marshal(v)
+			*fields = append(*fields, field{path, string(data)})
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				*fields = append(*fields, field{path, n})
+			} else if f, err := v.Float64(); err == nil {
+				*fields = append(*fields, field{path, f})
+			} else {
+				*fields = append(*fields, field{path, v.String()})
+			}
+		default:
+			*fields = append(*fields, field{path, v})
+		}
+	}
+}
+
+// Close commits the records and closes the database.
+func (s *SQLite) Close() error {
+	err := s.tx.Commit()
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Abort rolls back the records and closes the database.
+func (s *SQLite) Abort() error {
+	err := s.tx.Rollback()
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
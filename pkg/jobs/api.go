@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/config"
+)
+
+// maxRequestSize bounds the body of a job submission.
+const maxRequestSize = 1 << 20
+
+// jobOptions are the settings a job submission may override. The others,
+// such as output paths and keys, can only be set by the server's config
+// file and its profiles.
+var jobOptions = map[string]bool{
+	"endpoint":            true,
+	"query":               true,
+	"batch_size":          true,
+	"page_size":           true,
+	"rate_limit":          true,
+	"rate_burst":          true,
+	"adaptive_rate":       true,
+	"request_retry_count": true,
+	"retry_delay":         true,
+	"normalize":           true,
+	"cache":               true,
+	"redact":              true,
+	"compression":         true,
+	"compression_level":   true,
+	"format":              true,
+}
+
+// JobRequest is the body of a job submission. The controller and
+// credentials default to those of the server's config file.
+type JobRequest struct {
+	Controller string         `json:"controller"`
+	Username   string         `json:"username"`
+	Password   string         `json:"password"`
+	Profile    string         `json:"profile"` // name of a profile in the server's config file
+	Options    map[string]any `json:"options"` // settings by their config file keys
+}
+
+// API serves the job endpoints:
+//
+//	POST   /jobs              submit a job
+//	GET    /jobs              list the jobs, newest first
+//	GET    /jobs/{id}         status and progress of a job
+//	GET    /jobs/{id}/log     log of a job as JSON lines, followed until it ends
+//	GET    /jobs/{id}/archive download the archive of a finished job
+//	DELETE /jobs/{id}         cancel a queued job or remove a finished one
+type API struct {
+	Queue *Queue
+	Base  *config.Config // settings every job starts from
+	Dir   string         // directory for the archives of submitted jobs
+	Token string         // bearer token required by every endpoint, if set
+}
+
+// Register adds the job endpoints to mux.
+func (a *API) Register(mux *http.ServeMux) {
+	mux.Handle("POST /jobs", a.auth(a.submit))
+	mux.Handle("GET /jobs", a.auth(a.list))
+	mux.Handle("GET /jobs/{id}", a.auth(a.status))
+	mux.Handle("GET /jobs/{id}/log", a.auth(a.log))
+	mux.Handle("GET /jobs/{id}/archive", a.auth(a.archive))
+	mux.Handle("DELETE /jobs/{id}", a.auth(a.remove))
+}
+
+// auth rejects requests without the bearer token, when one is set.
+func (a *API) auth(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.Token != "" {
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+				return
+			}
+		}
+		h(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+// job returns the job named in the request path, answering 404 if there is
+// none.
+func (a *API) job(w http.ResponseWriter, r *http.Request) *Job {
+	j := a.Queue.Get(r.PathValue("id"))
+	if j == nil {
+		writeError(w, http.StatusNotFound, "no such job")
+	}
+	return j
+}
+
+func (a *API) submit(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid job request: %v", err))
+		return
+	}
+	j, err := a.newJob(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := a.Queue.Submit(j); err != nil {
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+j.ID)
+	writeJSON(w, http.StatusAccepted, j.Status())
+}
+
+// newJob builds the job for req: the base settings, then the profile, then
+// the options. Outputs other than the archive are dropped, since jobs run
+// concurrently and would overwrite each other's.
+func (a *API) newJob(req JobRequest) (*Job, error) {
+	cfg := *a.Base
+	cfg.Query = maps.Clone(a.Base.Query) // Apply merges into it
+	if req.Profile != "" {
+		profile, ok := a.Base.Profiles[req.Profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", req.Profile)
+		}
+		if err := cfg.Apply(profile); err != nil {
+			return nil, fmt.Errorf("profile %q: %v", req.Profile, err)
+		}
+	}
+	for key := range req.Options {
+		if !jobOptions[key] {
+			return nil, fmt.Errorf("option %q cannot be set by a job", key)
+		}
+	}
+	if err := cfg.Apply(req.Options); err != nil {
+		return nil, err
+	}
+	cfg.VolumeSize = ""
+	cfg.Baseline = ""
+	cfg.DBOutput = ""
+	cfg.SQLiteOutput = ""
+	cfg.CheckOutput = ""
+	cfg.ReportOutput = ""
+	cfg.TraceOutput = ""
+	cfg.MetricsListen = ""
+
+	if req.Controller != "" {
+		cfg.URL = req.Controller
+		cfg.Username, cfg.Password = "", ""
+		cfg.ControllerVersion = ""
+	}
+	if req.Username != "" {
+		cfg.Username = req.Username
+	}
+	if req.Password != "" {
+		cfg.Password = req.Password
+	}
+	if cfg.URL == "" || cfg.Username == "" || cfg.Password == "" {
+		return nil, fmt.Errorf("controller, username and password are required")
+	}
+	if err := cfg.NormalizeAndPrompt(); err != nil {
+		return nil, err
+	}
+
+	format, err := archive.ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = archive.FormatFromName(cfg.Output)
+	}
+	if format == archive.FormatDir {
+		return nil, fmt.Errorf("the dir format cannot be downloaded; use zip, tar.gz or tar.zst")
+	}
+
+	j := NewJob(&cfg, "api", req.Profile)
+	j.discard = true
+	stem := filepath.Base(archive.WithExtension(cfg.Output, archive.FormatDir))
+	cfg.Output = filepath.Join(a.Dir, archive.WithExtension(stem+"-"+j.ID, format))
+	cfg.Format = string(format)
+	return j, nil
+}
+
+func (a *API) list(w http.ResponseWriter, r *http.Request) {
+	statuses := []Status{}
+	for _, j := range a.Queue.List() {
+		statuses = append(statuses, j.Status())
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (a *API) status(w http.ResponseWriter, r *http.Request) {
+	if j := a.job(w, r); j != nil {
+		writeJSON(w, http.StatusOK, j.Status())
+	}
+}
+
+// log writes the job's log. Unless follow=false is given, the response
+// stays open and receives new lines until the job ends.
+func (a *API) log(w http.ResponseWriter, r *http.Request) {
+	j := a.job(w, r)
+	if j == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if r.URL.Query().Get("follow") == "false" {
+		w.Write(j.log.Bytes())
+		return
+	}
+	rc := http.NewResponseController(w)
+	j.log.Follow(r.Context(), func(p []byte) error {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+}
+
+func (a *API) archive(w http.ResponseWriter, r *http.Request) {
+	j := a.job(w, r)
+	if j == nil {
+		return
+	}
+	st := j.Status()
+	if st.State != Succeeded && st.State != Partial {
+		writeError(w, http.StatusConflict, fmt.Sprintf("job is %s; it has no archive", st.State))
+		return
+	}
+	f, err := os.Open(st.Archive)
+	if err != nil {
+		writeError(w, http.StatusGone, "archive is no longer available")
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		writeError(w, http.StatusConflict, "archive is not a single file")
+		return
+	}
+	name := filepath.Base(st.Archive)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, name, fi.ModTime(), f)
+}
+
+func (a *API) remove(w http.ResponseWriter, r *http.Request) {
+	j := a.job(w, r)
+	if j == nil {
+		return
+	}
+	if !a.Queue.Cancel(j) && !a.Queue.Remove(j) {
+		writeError(w, http.StatusConflict, "job is running")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,377 @@
+// Package jobs queues collection jobs, runs them within concurrency caps and
+// serves an HTTP API to submit jobs, follow them and download their archives.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+	"ndfc-collector/pkg/log"
+	"ndfc-collector/pkg/metrics"
+)
+
+// maxFinished is the number of finished jobs remembered; older ones are
+// forgotten, and the archives of API jobs removed.
+const maxFinished = 100
+
+// State is the state of a job.
+type State string
+
+// Job states. A partial job wrote an archive but some requests failed.
+const (
+	Queued    State = "queued"
+	Running   State = "running"
+	Succeeded State = "succeeded"
+	Partial   State = "partial"
+	Failed    State = "failed"
+	Canceled  State = "canceled"
+)
+
+// finished reports whether a job in state s has ended.
+func (s State) finished() bool {
+	return s != Queued && s != Running
+}
+
+// Result is the outcome of a collection.
+type Result struct {
+	Archive string          // archive file name, including any .enc suffix
+	Summary metrics.Summary // measurements of the run
+	Err     error           // first request that failed; the archive holds the rest
+}
+
+// Collector runs the collection described by cfg, reporting to run. An
+// error is returned when no archive could be written.
+type Collector func(cfg *config.Config, run *cli.Run) (Result, error)
+
+// Job is a queued, running or finished collection.
+type Job struct {
+	ID      string
+	Source  string // who submitted the job: api or schedule
+	Profile string // profile the job's settings were taken from, if any
+	cfg     *config.Config
+	discard bool // remove the archive when the job is forgotten
+	log     *logBuffer
+	done    chan struct{}
+
+	mu       sync.Mutex
+	state    State
+	created  time.Time
+	started  time.Time
+	finished time.Time
+	run      *cli.Run
+	result   Result
+	err      error
+}
+
+// NewJob returns a job collecting with cfg, which the job takes over.
+func NewJob(cfg *config.Config, source, profile string) *Job {
+	return &Job{
+		ID:      newID(),
+		Source:  source,
+		Profile: profile,
+		cfg:     cfg,
+		log:     newLogBuffer(),
+		done:    make(chan struct{}),
+		state:   Queued,
+		created: time.Now(),
+	}
+}
+
+// newID returns a random job ID.
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Done is closed when the job has finished.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Result returns the outcome of a finished job.
+func (j *Job) Result() (Result, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.result, j.err
+}
+
+// Progress is the progress of a running job, or the totals of a finished
+// one.
+type Progress struct {
+	Seconds  float64 `json:"seconds"`
+	Levels   int     `json:"levels"` // dependency levels finished
+	Requests int     `json:"requests"`
+	Failures int     `json:"failures"`
+	Retries  int     `json:"retries"`
+	Carried  int     `json:"carried"` // responses carried forward from the baseline
+	Bytes    int64   `json:"bytes"`
+}
+
+// Status is the state of a job as reported by the API.
+type Status struct {
+	ID         string    `json:"id"`
+	Source     string    `json:"source"`
+	Profile    string    `json:"profile,omitempty"`
+	Controller string    `json:"controller"`
+	State      State     `json:"state"`
+	Created    time.Time `json:"created"`
+	Started    time.Time `json:"started,omitzero"`
+	Finished   time.Time `json:"finished,omitzero"`
+	Progress   *Progress `json:"progress,omitempty"`
+	Archive    string    `json:"archive,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Status returns the current state of the job.
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	st := Status{
+		ID:         j.ID,
+		Source:     j.Source,
+		Profile:    j.Profile,
+		Controller: j.cfg.URL,
+		State:      j.state,
+		Created:    j.created,
+		Started:    j.started,
+		Finished:   j.finished,
+		Archive:    j.result.Archive,
+	}
+	if j.run != nil {
+		s := j.run.Metrics.Summary()
+		if j.state.finished() {
+			s = j.result.Summary
+		}
+		st.Progress = &Progress{
+			Seconds:  s.Seconds,
+			Levels:   len(s.Levels),
+			Requests: s.Requests,
+			Failures: s.Failures,
+			Retries:  s.Retries,
+			Carried:  s.Carried,
+			Bytes:    s.Bytes,
+		}
+	}
+	switch {
+	case j.err != nil:
+		st.Error = j.err.Error()
+	case j.result.Err != nil:
+		st.Error = j.result.Err.Error()
+	}
+	return st
+}
+
+// ErrQueueFull is returned by Submit when too many jobs are waiting.
+var ErrQueueFull = errors.New("too many jobs are waiting; try again later")
+
+// Queue runs jobs in submission order, at most maxJobs at a time and at
+// most perController at a time against any one controller, so that jobs
+// do not overload it. A job whose controller is busy does not hold up jobs
+// for other controllers. At most maxQueued jobs wait to start.
+type Queue struct {
+	collect       Collector
+	maxJobs       int
+	perController int
+	maxQueued     int
+
+	mu      sync.Mutex
+	jobs    []*Job // in submission order
+	running map[string]int
+	active  int
+}
+
+// NewQueue returns a queue running jobs with collect. Caps below one are
+// treated as one.
+func NewQueue(collect Collector, maxJobs, perController, maxQueued int) *Queue {
+	return &Queue{
+		collect:       collect,
+		maxJobs:       max(maxJobs, 1),
+		perController: max(perController, 1),
+		maxQueued:     max(maxQueued, 1),
+		running:       map[string]int{},
+	}
+}
+
+// Submit queues j and starts it if a slot is free. It returns ErrQueueFull,
+// without queueing j, when maxQueued jobs are already waiting.
+func (q *Queue) Submit(j *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	queued := 0
+	for _, k := range q.jobs {
+		k.mu.Lock()
+		if k.state == Queued {
+			queued++
+		}
+		k.mu.Unlock()
+	}
+	if queued >= q.maxQueued {
+		return ErrQueueFull
+	}
+	q.jobs = append(q.jobs, j)
+	q.dispatch()
+	return nil
+}
+
+// Get returns the job with the given ID, or nil.
+func (q *Queue) Get(id string) *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, j := range q.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// List returns every remembered job, newest first.
+func (q *Queue) List() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]*Job, 0, len(q.jobs))
+	for i := len(q.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, q.jobs[i])
+	}
+	return jobs
+}
+
+// Cancel cancels a queued job. It reports false if the job has started.
+func (q *Queue) Cancel(j *Job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.state != Queued {
+		return false
+	}
+	j.state = Canceled
+	j.finished = time.Now()
+	j.log.Close()
+	close(j.done)
+	return true
+}
+
+// Remove forgets a finished job, removing its archive if it was submitted
+// through the API. It reports false if the job has not finished.
+func (q *Queue) Remove(j *Job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j.mu.Lock()
+	finished := j.state.finished()
+	j.mu.Unlock()
+	if !finished {
+		return false
+	}
+	for i, k := range q.jobs {
+		if k == j {
+			q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+			break
+		}
+	}
+	j.removeArchive()
+	return true
+}
+
+func (j *Job) removeArchive() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.discard && j.result.Archive != "" {
+		os.Remove(j.result.Archive)
+	}
+}
+
+// dispatch starts the queued jobs that fit within the caps and forgets the
+// oldest finished jobs beyond maxFinished. q.mu must be held.
+func (q *Queue) dispatch() {
+	var finished []*Job
+	for _, j := range q.jobs {
+		j.mu.Lock()
+		state, controller := j.state, j.cfg.URL
+		j.mu.Unlock()
+		switch {
+		case state.finished():
+			finished = append(finished, j)
+		case state == Queued && q.active < q.maxJobs && q.running[controller] < q.perController:
+			q.active++
+			q.running[controller]++
+			q.start(j)
+		}
+	}
+	for len(finished) > maxFinished {
+		j := finished[0]
+		finished = finished[1:]
+		for i, k := range q.jobs {
+			if k == j {
+				q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+				break
+			}
+		}
+		j.removeArchive()
+	}
+}
+
+// start runs j in the background. q.mu must be held.
+func (q *Queue) start(j *Job) {
+	logger := log.Tee(j.log).With().Str("job", j.ID).Logger()
+	run := cli.NewRun(logger)
+	j.mu.Lock()
+	j.state = Running
+	j.started = time.Now()
+	j.run = run
+	j.mu.Unlock()
+	logger.Info().Str("controller", j.cfg.URL).Str("source", j.Source).Msg("Job started.")
+
+	go func() {
+		res, err := q.collect(j.cfg, run)
+
+		j.mu.Lock()
+		j.result, j.err = res, err
+		j.finished = time.Now()
+		switch {
+		case err != nil:
+			j.state = Failed
+			logger.Error().Err(err).Msg("Job failed.")
+		case res.Err != nil:
+			j.state = Partial
+			logger.Warn().Err(res.Err).Msg("Job finished; some data could not be fetched.")
+		default:
+			j.state = Succeeded
+			logger.Info().Msg("Job finished.")
+		}
+		controller := j.cfg.URL
+		j.mu.Unlock()
+		j.log.Close()
+		close(j.done)
+
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.active--
+		q.running[controller]--
+		q.dispatch()
+	}()
+}
@@ -0,0 +1,349 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ndfc-collector/pkg/cli"
+	"ndfc-collector/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCollector writes an archive for each job once it is released, by
+// closing the channel registered for the job's controller.
+type fakeCollector struct {
+	mu      sync.Mutex
+	release map[string]chan struct{}
+	started chan string
+	err     error
+	partial error
+}
+
+func newFakeCollector() *fakeCollector {
+	return &fakeCollector{release: map[string]chan struct{}{}, started: make(chan string, 10)}
+}
+
+func (f *fakeCollector) gate(controller string) chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.release[controller] == nil {
+		f.release[controller] = make(chan struct{})
+	}
+	return f.release[controller]
+}
+
+func (f *fakeCollector) collect(cfg *config.Config, run *cli.Run) (Result, error) {
+	f.started <- cfg.URL
+	<-f.gate(cfg.URL)
+	run.Log.Info().Msg("collecting")
+	run.Metrics.Request("inventory/switches", time.Millisecond, 10, nil)
+	if f.err != nil {
+		return Result{}, f.err
+	}
+	if err := os.WriteFile(cfg.Output, []byte("archive"), 0o644); err != nil {
+		return Result{}, err
+	}
+	return Result{Archive: cfg.Output, Summary: run.Metrics.Summary(), Err: f.partial}, nil
+}
+
+func testJob(t *testing.T, controller string) *Job {
+	cfg := config.New()
+	cfg.URL = controller
+	cfg.Output = filepath.Join(t.TempDir(), "out.zip")
+	return NewJob(&cfg, "api", "")
+}
+
+func wait(t *testing.T, j *Job) {
+	t.Helper()
+	select {
+	case <-j.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job %s did not finish", j.ID)
+	}
+}
+
+func TestQueueCaps(t *testing.T) {
+	f := newFakeCollector()
+	q := NewQueue(f.collect, 2, 1, 10)
+	a1, a2, b := testJob(t, "a"), testJob(t, "a"), testJob(t, "b")
+	require.NoError(t, q.Submit(a1))
+	require.NoError(t, q.Submit(a2))
+	require.NoError(t, q.Submit(b))
+
+	assert.ElementsMatch(t, []string{"a", "b"}, []string{<-f.started, <-f.started})
+	assert.Equal(t, Running, a1.Status().State)
+	assert.Equal(t, Queued, a2.Status().State, "one job at a time per controller")
+	assert.Equal(t, Running, b.Status().State, "a busy controller does not hold up others")
+
+	close(f.gate("a"))
+	wait(t, a1)
+	assert.Equal(t, "a", <-f.started)
+	wait(t, a2)
+	close(f.gate("b"))
+	wait(t, b)
+
+	for _, j := range []*Job{a1, a2, b} {
+		st := j.Status()
+		assert.Equal(t, Succeeded, st.State)
+		assert.FileExists(t, st.Archive)
+		require.NotNil(t, st.Progress)
+		assert.Equal(t, 1, st.Progress.Requests)
+		assert.False(t, st.Finished.Before(st.Started))
+	}
+	assert.Equal(t, []*Job{b, a2, a1}, q.List())
+}
+
+func TestQueueStates(t *testing.T) {
+	f := newFakeCollector()
+	close(f.gate("a"))
+	q := NewQueue(f.collect, 1, 1, 10)
+
+	f.partial = errors.New("request failed for /x")
+	j := testJob(t, "a")
+	require.NoError(t, q.Submit(j))
+	wait(t, j)
+	assert.Equal(t, Partial, j.Status().State)
+	assert.Equal(t, "request failed for /x", j.Status().Error)
+
+	f.err = errors.New("cannot authenticate")
+	j = testJob(t, "a")
+	require.NoError(t, q.Submit(j))
+	wait(t, j)
+	assert.Equal(t, Failed, j.Status().State)
+	assert.Empty(t, j.Status().Archive)
+	_, err := j.Result()
+	assert.EqualError(t, err, "cannot authenticate")
+}
+
+func TestQueueCancelAndRemove(t *testing.T) {
+	f := newFakeCollector()
+	q := NewQueue(f.collect, 1, 1, 10)
+	running, queued := testJob(t, "a"), testJob(t, "a")
+	running.discard = true
+	require.NoError(t, q.Submit(running))
+	require.NoError(t, q.Submit(queued))
+	<-f.started
+
+	assert.False(t, q.Cancel(running))
+	assert.False(t, q.Remove(running), "a running job cannot be removed")
+	assert.True(t, q.Cancel(queued))
+	wait(t, queued)
+	assert.Equal(t, Canceled, queued.Status().State)
+
+	close(f.gate("a"))
+	wait(t, running)
+	archive := running.Status().Archive
+	assert.FileExists(t, archive)
+	assert.True(t, q.Remove(running))
+	assert.NoFileExists(t, archive, "the archive of an API job is removed with it")
+	assert.Nil(t, q.Get(running.ID))
+	assert.Equal(t, []*Job{queued}, q.List())
+}
+
+func TestQueueFull(t *testing.T) {
+	f := newFakeCollector()
+	q := NewQueue(f.collect, 1, 1, 1)
+	running, queued := testJob(t, "a"), testJob(t, "a")
+	require.NoError(t, q.Submit(running))
+	<-f.started
+	require.NoError(t, q.Submit(queued))
+	assert.ErrorIs(t, q.Submit(testJob(t, "a")), ErrQueueFull, "running jobs do not count")
+	assert.Len(t, q.List(), 2)
+
+	assert.True(t, q.Cancel(queued))
+	assert.NoError(t, q.Submit(testJob(t, "a")), "a slot frees up once a job leaves the queue")
+	close(f.gate("a"))
+}
+
+func TestLogBufferFollow(t *testing.T) {
+	b := newLogBuffer()
+	b.Write([]byte("one\n"))
+
+	var got bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- b.Follow(context.Background(), func(p []byte) error {
+			got.Write(p)
+			return nil
+		})
+	}()
+	b.Write([]byte("two\n"))
+	b.Close()
+	b.Write([]byte("late\n"))
+	require.NoError(t, <-done)
+	assert.True(t, strings.HasPrefix(got.String(), "one\ntwo\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	open := newLogBuffer()
+	assert.ErrorIs(t, open.Follow(ctx, func([]byte) error { return nil }), context.Canceled)
+}
+
+func testAPI(t *testing.T, f *fakeCollector) (*API, *httptest.Server) {
+	t.Helper()
+	base := config.New()
+	base.URL = "ndfc.example.com"
+	base.Username = "admin"
+	base.Password = "secret"
+	base.CheckOutput = "checks.txt"
+	base.Profiles = map[string]map[string]any{
+		"quick": {"endpoint": "/api/v1/fabrics", "batch_size": 2},
+	}
+	api := &API{
+		Queue: NewQueue(f.collect, 2, 1, 10),
+		Base:  &base,
+		Dir:   t.TempDir(),
+		Token: "t0k3n",
+	}
+	mux := http.NewServeMux()
+	api.Register(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return api, srv
+}
+
+func do(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer t0k3n")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+func decode[T any](t *testing.T, res *http.Response) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&v))
+	return v
+}
+
+func TestAPI(t *testing.T) {
+	f := newFakeCollector()
+	api, srv := testAPI(t, f)
+
+	res, err := http.Post(srv.URL+"/jobs", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res = do(t, "POST", srv.URL+"/jobs", `{"profile":"quick","options":{"page_size":500}}`)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	st := decode[Status](t, res)
+	assert.Equal(t, "/jobs/"+st.ID, res.Header.Get("Location"))
+	assert.Equal(t, "ndfc.example.com", st.Controller)
+	assert.Equal(t, "quick", st.Profile)
+	<-f.started
+
+	j := api.Queue.Get(st.ID)
+	require.NotNil(t, j)
+	assert.Equal(t, "/api/v1/fabrics", j.cfg.Endpoint)
+	assert.Equal(t, 2, j.cfg.BatchSize)
+	assert.Equal(t, 500, j.cfg.PageSize)
+	assert.Empty(t, j.cfg.CheckOutput, "side outputs are dropped")
+	assert.Equal(t, filepath.Join(api.Dir, "ndfc-collection-data-"+st.ID+".zip"), j.cfg.Output)
+
+	res = do(t, "GET", srv.URL+"/jobs/"+st.ID+"/archive", "")
+	assert.Equal(t, http.StatusConflict, res.StatusCode, "no archive while running")
+	res = do(t, "DELETE", srv.URL+"/jobs/"+st.ID, "")
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	// The log is followed until the job ends.
+	logs := make(chan string)
+	go func() {
+		res := do(t, "GET", srv.URL+"/jobs/"+st.ID+"/log", "")
+		data, _ := io.ReadAll(res.Body)
+		logs <- string(data)
+	}()
+	close(f.gate("ndfc.example.com"))
+	wait(t, j)
+	log := <-logs
+	assert.Contains(t, log, `"message":"Job started."`)
+	assert.Contains(t, log, `"message":"collecting"`)
+	assert.Contains(t, log, `"job":"`+st.ID+`"`)
+
+	res = do(t, "GET", srv.URL+"/jobs/"+st.ID, "")
+	st = decode[Status](t, res)
+	assert.Equal(t, Succeeded, st.State)
+	assert.Equal(t, 1, st.Progress.Requests)
+
+	res = do(t, "GET", srv.URL+"/jobs", "")
+	assert.Len(t, decode[[]Status](t, res), 1)
+
+	res = do(t, "GET", srv.URL+"/jobs/"+st.ID+"/archive", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	data, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(data))
+	assert.Contains(t, res.Header.Get("Content-Disposition"), "ndfc-collection-data-"+st.ID+".zip")
+
+	res = do(t, "DELETE", srv.URL+"/jobs/"+st.ID, "")
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	assert.NoFileExists(t, st.Archive)
+	res = do(t, "GET", srv.URL+"/jobs/"+st.ID, "")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestAPIRejects(t *testing.T) {
+	_, srv := testAPI(t, newFakeCollector())
+	for body, msg := range map[string]string{
+		`{"options":{"check_output":"/etc/x"}}`:        `option "check_output" cannot be set by a job`,
+		`{"options":{"page_size":"many"}}`:             "invalid settings",
+		`{"profile":"slow"}`:                           `unknown profile "slow"`,
+		`{"controller":"10.0.0.1"}`:                    "controller, username and password are required",
+		`{"options":{"format":"dir"}}`:                 "dir format",
+		`{"controler":"10.0.0.1"}`:                     "unknown field",
+		`{"controller":"10.0.0.1","username":"admin"}`: "required",
+	} {
+		res := do(t, "POST", srv.URL+"/jobs", body)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+		assert.Contains(t, decode[map[string]string](t, res)["error"], msg, body)
+	}
+}
+
+func TestAPI_QueueFull(t *testing.T) {
+	f := newFakeCollector()
+	api, srv := testAPI(t, f)
+	api.Queue = NewQueue(f.collect, 1, 1, 1)
+
+	res := do(t, "POST", srv.URL+"/jobs", `{}`)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	<-f.started
+	res = do(t, "POST", srv.URL+"/jobs", `{}`)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	res = do(t, "POST", srv.URL+"/jobs", `{}`)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, "60", res.Header.Get("Retry-After"))
+	close(f.gate("ndfc.example.com"))
+}
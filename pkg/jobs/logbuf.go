@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"sync"
+)
+
+// logBuffer keeps the log of a job and lets readers follow it as it grows.
+type logBuffer struct {
+	mu      sync.Mutex
+	data    []byte
+	closed  bool
+	changed chan struct{} // closed and replaced on every write
+}
+
+func newLogBuffer() *logBuffer {
+	return &logBuffer{changed: make(chan struct{})}
+}
+
+// Write appends p to the log.
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if !b.closed {
+		close(b.changed)
+		b.changed = make(chan struct{})
+	}
+	return len(p), nil
+}
+
+// Close marks the log complete, releasing followers.
+func (b *logBuffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.changed)
+	}
+}
+
+// Follow calls fn with each part of the log from the start, waiting for
+// more until the log is closed or ctx is done.
+func (b *logBuffer) Follow(ctx context.Context, fn func([]byte) error) error {
+	off := 0
+	for {
+		b.mu.Lock()
+		chunk := b.data[off:len(b.data):len(b.data)]
+		closed, changed := b.closed, b.changed
+		b.mu.Unlock()
+
+		if len(chunk) > 0 {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+			off += len(chunk)
+			continue
+		}
+		if closed {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Bytes returns the log so far.
+func (b *logBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.data...)
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Items reads a JSON document from r and calls fn with each item of the
+// list at listPath, following the same conventions as Project: when the
+// root is an array its elements are the items, and when listPath does not
+// lead to an array the root object is the only item.
+//
+// Items of a root array or of a list directly under the root are decoded
+// one at a time. Other members of the root object are buffered in case the
+// list is not found.
+func Items(r io.Reader, listPath string, fn func(item json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	switch tok {
+	case json.Delim('['):
+		return eachItem(dec, fn)
+	case json.Delim('{'):
+	default:
+		// Scalar documents have no items.
+		return nil
+	}
+
+	var head, rest string
+	if listPath != "" && listPath != "@this" {
+		head, rest, _ = strings.Cut(listPath, ".")
+	}
+
+	// root collects the members of the root object, in order.
+	var root bytes.Buffer
+	root.WriteByte('{')
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("decoding object: %w", err)
+		}
+		key, _ := tok.(string)
+
+		if head != "" && key == head && rest == "" {
+			tok, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("decoding %s: %w", key, err)
+			}
+			if tok == json.Delim('[') {
+				return eachItem(dec, fn)
+			}
+			// Not a list after all; keep the value as the root's member.
+			if err := appendMember(&root, key, tok, dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("decoding %s: %w", key, err)
+		}
+		if head != "" && key == head {
+			if list := gjson.GetBytes(raw, rest); list.IsArray() {
+				var ferr error
+				list.ForEach(func(_, item gjson.Result) bool {
+					ferr = fn(json.RawMessage(item.Raw))
+					return ferr == nil
+				})
+				return ferr
+			}
+		}
+		writeMember(&root, key, raw)
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("decoding object: %w", err)
+	}
+	root.WriteByte('}')
+	return fn(json.RawMessage(root.Bytes()))
+}
+
+// eachItem calls fn for every element of an array whose opening bracket
+// has already been consumed.
+func eachItem(dec *json.Decoder, fn func(json.RawMessage) error) error {
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("decoding list item: %w", err)
+		}
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("decoding list: %w", err)
+	}
+	return nil
+}
+
+// appendMember adds a member whose first token has already been read.
+// The decoder uses json.Number, so scalars are re-encoded unchanged.
+func appendMember(buf *bytes.Buffer, key string, tok json.Token, dec *json.Decoder) error {
+	if tok != json.Delim('{') {
+		writeMember(buf, key, encode(tok))
+		return nil
+	}
+	// Rebuild the object from its remaining members.
+	var obj bytes.Buffer
+	obj.WriteByte('{')
+	for dec.More() {
+		k, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("decoding %s: %w", key, err)
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("decoding %s: %w", key, err)
+		}
+		name, _ := k.(string)
+		writeMember(&obj, name, v)
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("decoding %s: %w", key, err)
+	}
+	obj.WriteByte('}')
+	writeMember(buf, key, obj.Bytes())
+	return nil
+}
+
+// encode renders a JSON scalar or string without HTML escaping.
+func encode(v any) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// writeMember appends "key":raw to an object being built in buf.
+func writeMember(buf *bytes.Buffer, key string, raw []byte) {
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	buf.Write(encode(key))
+	buf.WriteByte(':')
+	buf.Write(raw)
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonstream
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func items(t *testing.T, doc, listPath string) []string {
+	t.Helper()
+	var got []string
+	require.NoError(t, Items(strings.NewReader(doc), listPath, func(item json.RawMessage) error {
+		got = append(got, string(item))
+		return nil
+	}))
+	return got
+}
+
+func TestItems(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc, listPath string
+		want                []string
+	}{
+		{"root array", `[{"a":1},{"a":2}]`, "@this", []string{`{"a":1}`, `{"a":2}`}},
+		{"root array ignores path", `[1,2]`, "items", []string{"1", "2"}},
+		{"wrapped list", `{"total":2,"fabrics":[{"n":"f1"},{"n":"f2"}],"x":1}`, "fabrics", []string{`{"n":"f1"}`, `{"n":"f2"}`}},
+		{"nested list", `{"a":{"b":[1,2]}}`, "a.b", []string{"1", "2"}},
+		{"empty list", `{"fabrics":[]}`, "fabrics", nil},
+		{
+			"root object without list",
+			`{"id":12345678901234567890,"s":"<x>","fabrics":{"n":"f1"},"o":{"p":1}}`,
+			"fabrics",
+			[]string{`{"id":12345678901234567890,"s":"<x>","fabrics":{"n":"f1"},"o":{"p":1}}`},
+		},
+		{"root object", `{"a":1}`, "", []string{`{"a":1}`}},
+		{"missing nested list", `{"a":{"c":1}}`, "a.b", []string{`{"a":{"c":1}}`}},
+		{"scalar", `"x"`, "", nil},
+		{"empty", "", "", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, items(t, tc.doc, tc.listPath))
+		})
+	}
+}
+
+func TestItems_Invalid(t *testing.T) {
+	err := Items(strings.NewReader(`{"fabrics":[{"a":`), "fabrics", func(json.RawMessage) error { return nil })
+	assert.Error(t, err)
+}
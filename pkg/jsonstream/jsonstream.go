@@ -0,0 +1,204 @@
+// Package jsonstream extracts small projections from large JSON documents
+// without holding the whole document in memory.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Project reads a JSON document from r and returns a compact copy of the
+// items at listPath containing only the given keys.
+//
+// listPath follows the requests.yaml list_path convention: "" and "@this"
+// use the document root, anything else is a dot-notation path to an array
+// inside the root object. When the root is an array each element is
+// projected and the result is an array; when listPath does not lead to an
+// array the root object itself is projected, matching how single-object
+// endpoints are expanded.
+//
+// Only one list item is decoded at a time, so memory use is bounded by the
+// largest item rather than the whole response.
+func Project(r io.Reader, listPath string, keys []string) (gjson.Result, error) {
+	p := projector{
+		dec:   json.NewDecoder(r),
+		keys:  keys,
+		heads: make(map[string]bool, len(keys)),
+	}
+	for _, key := range keys {
+		head, _, _ := strings.Cut(key, ".")
+		p.heads[head] = true
+	}
+
+	var path []string
+	if listPath != "" && listPath != "@this" {
+		path = strings.Split(listPath, ".")
+	}
+
+	tok, err := p.dec.Token()
+	if err == io.EOF {
+		return gjson.Result{}, nil
+	}
+	if err != nil {
+		return gjson.Result{}, fmt.Errorf("decoding response: %w", err)
+	}
+
+	switch tok {
+	case json.Delim('['):
+		return p.array()
+	case json.Delim('{'):
+		root := map[string]json.RawMessage{}
+		list, found, err := p.object(path, root)
+		if err != nil {
+			return gjson.Result{}, err
+		}
+		if found {
+			return list, nil
+		}
+		raw, err := json.Marshal(root)
+		if err != nil {
+			return gjson.Result{}, err
+		}
+		return gjson.Parse(p.item(raw)), nil
+	}
+	// Scalar documents have nothing to expand.
+	return gjson.Result{}, nil
+}
+
+type projector struct {
+	dec   *json.Decoder
+	keys  []string
+	heads map[string]bool // top-level field names referenced by keys
+}
+
+// array projects every item of an array whose opening bracket has already
+// been consumed.
+func (p projector) array() (gjson.Result, error) {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i := 0; p.dec.More(); i++ {
+		var raw json.RawMessage
+		if err := p.dec.Decode(&raw); err != nil {
+			return gjson.Result{}, fmt.Errorf("decoding list item: %w", err)
+		}
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(p.item(raw))
+	}
+	if _, err := p.dec.Token(); err != nil {
+		return gjson.Result{}, fmt.Errorf("decoding list: %w", err)
+	}
+	sb.WriteByte(']')
+	return gjson.Parse(sb.String()), nil
+}
+
+// object scans an object whose opening brace has already been consumed,
+// descending along path towards the list. Fields referenced by keys are
+// copied into collect (when non-nil) so the object itself can be projected
+// if the list is not found.
+func (p projector) object(path []string, collect map[string]json.RawMessage) (gjson.Result, bool, error) {
+	for p.dec.More() {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return gjson.Result{}, false, fmt.Errorf("decoding object: %w", err)
+		}
+		key, _ := tok.(string)
+
+		if len(path) > 0 && key == path[0] {
+			tok, err := p.dec.Token()
+			if err != nil {
+				return gjson.Result{}, false, fmt.Errorf("decoding %s: %w", key, err)
+			}
+			switch {
+			case tok == json.Delim('[') && len(path) == 1:
+				list, err := p.array()
+				return list, err == nil, err
+			case tok == json.Delim('{') && len(path) > 1:
+				list, found, err := p.object(path[1:], nil)
+				if found || err != nil {
+					return list, found, err
+				}
+			default:
+				if err := p.skip(tok); err != nil {
+					return gjson.Result{}, false, err
+				}
+			}
+			continue
+		}
+
+		if collect != nil && p.heads[key] {
+			var raw json.RawMessage
+			if err := p.dec.Decode(&raw); err != nil {
+				return gjson.Result{}, false, fmt.Errorf("decoding %s: %w", key, err)
+			}
+			collect[key] = raw
+			continue
+		}
+
+		tok, err = p.dec.Token()
+		if err != nil {
+			return gjson.Result{}, false, fmt.Errorf("decoding %s: %w", key, err)
+		}
+		if err := p.skip(tok); err != nil {
+			return gjson.Result{}, false, err
+		}
+	}
+	if _, err := p.dec.Token(); err != nil {
+		return gjson.Result{}, false, fmt.Errorf("decoding object: %w", err)
+	}
+	return gjson.Result{}, false, nil
+}
+
+// skip discards the remainder of a value whose first token was tok.
+func (p projector) skip(tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return fmt.Errorf("skipping value: %w", err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// item returns a compact object holding only the scalar values at keys.
+func (p projector) item(raw []byte) string {
+	out := "{}"
+	for _, key := range p.keys {
+		if val := gjson.GetBytes(raw, key); val.Exists() && val.Type != gjson.JSON {
+			out, _ = sjson.SetRaw(out, key, val.Raw)
+		}
+	}
+	return out
+}
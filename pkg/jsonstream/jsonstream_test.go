@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonstream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func project(t *testing.T, doc, listPath string, keys ...string) string {
+	t.Helper()
+	res, err := Project(strings.NewReader(doc), listPath, keys)
+	require.NoError(t, err)
+	return res.Raw
+}
+
+func TestProject_WrappedObject(t *testing.T) {
+	// Typical NDFC response: the array is inside a named wrapper key.
+	doc := `{"meta":{"count":2},"fabrics":[{"name":"f1","x":[1,2]},{"name":"f2","x":{}}]}`
+	assert.Equal(t, `[{"name":"f1"},{"name":"f2"}]`, project(t, doc, "fabrics", "name"))
+}
+
+func TestProject_RootArray(t *testing.T) {
+	// list_path "@this": response is already a root array (e.g. the VRF endpoint).
+	doc := `[{"id":1,"big":"..."},{"id":2}]`
+	assert.Equal(t, `[{"id":1},{"id":2}]`, project(t, doc, "@this", "id"))
+}
+
+func TestProject_RootArrayIgnoresListPath(t *testing.T) {
+	doc := `[{"id":1}]`
+	assert.Equal(t, `[{"id":1}]`, project(t, doc, "fabrics", "id"))
+}
+
+func TestProject_EmptyPath(t *testing.T) {
+	// list_path "": single-object endpoint — the object itself is projected.
+	doc := `{"key":"value","other":{"deep":true}}`
+	assert.Equal(t, `{"key":"value"}`, project(t, doc, "", "key"))
+}
+
+func TestProject_MissingPath(t *testing.T) {
+	// list_path points to a non-existent key — fall back to the root object.
+	doc := `{"other":"value"}`
+	assert.Equal(t, `{"other":"value"}`, project(t, doc, "nothere", "other"))
+}
+
+func TestProject_PathNotArray(t *testing.T) {
+	doc := `{"fabrics":{"name":"f1"},"name":"root"}`
+	assert.Equal(t, `{"name":"root"}`, project(t, doc, "fabrics", "name"))
+}
+
+func TestProject_NestedPath(t *testing.T) {
+	doc := `{"skip":[{"a":1}],"data":{"meta":1,"items":[{"serialNumber":"SN1"}]}}`
+	assert.Equal(t, `[{"serialNumber":"SN1"}]`, project(t, doc, "data.items", "serialNumber"))
+}
+
+func TestProject_NestedKey(t *testing.T) {
+	doc := `[{"fabric":{"name":"f1","type":"VXLAN"}}]`
+	assert.Equal(t, `[{"fabric":{"name":"f1"}}]`, project(t, doc, "@this", "fabric.name"))
+}
+
+func TestProject_ItemsWithoutKeysAreKept(t *testing.T) {
+	doc := `[{"name":"f1"},{"other":1},{"name":{"nested":true}}]`
+	assert.Equal(t, `[{"name":"f1"},{},{}]`, project(t, doc, "@this", "name"))
+}
+
+func TestProject_MultipleKeys(t *testing.T) {
+	doc := `{"switches":[{"switchId":"S1","fabricName":"f1","model":"N9K"}]}`
+	assert.Equal(t, `[{"switchId":"S1","fabricName":"f1"}]`,
+		project(t, doc, "switches", "switchId", "fabricName"))
+}
+
+func TestProject_Scalar(t *testing.T) {
+	assert.Equal(t, "", project(t, `"ok"`, "", "name"))
+}
+
+func TestProject_Empty(t *testing.T) {
+	assert.Equal(t, "", project(t, ``, "", "name"))
+}
+
+func TestProject_Invalid(t *testing.T) {
+	_, err := Project(strings.NewReader(`{"fabrics":[{"name":`), "fabrics", []string{"name"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Normalize rewrites the JSON document read from r in a canonical form:
+// indented, with object keys sorted and, when idField is set, the items at
+// listPath sorted by their idField value. Two responses with the same
+// content therefore produce identical output regardless of the order NDFC
+// returned them in. Numbers are written exactly as received.
+//
+// Unlike Project, the whole document is held in memory.
+func Normalize(r io.Reader, w io.Writer, listPath, idField string) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("decoding response: unexpected data after JSON document")
+	}
+
+	if idField != "" {
+		if list, ok := listAt(doc, listPath); ok {
+			sortByID(list, strings.Split(idField, "."))
+		}
+	}
+
+	// Maps are encoded with sorted keys.
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// listAt returns the array at listPath, using the list_path convention
+// described on Project.
+func listAt(doc any, listPath string) ([]any, bool) {
+	if listPath != "" && listPath != "@this" {
+		for _, seg := range strings.Split(listPath, ".") {
+			obj, ok := doc.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			doc = obj[seg]
+		}
+	}
+	list, ok := doc.([]any)
+	return list, ok
+}
+
+// lookup returns the value at path in item.
+func lookup(item any, path []string) (any, bool) {
+	for _, seg := range path {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if item, ok = obj[seg]; !ok {
+			return nil, false
+		}
+	}
+	return item, true
+}
+
+// sortByID sorts list by the value at idPath in each item. Numbers sort
+// numerically, other values by their JSON text, and items without an ID
+// sort last in their original order.
+func sortByID(list []any, idPath []string) {
+	type keyed struct {
+		item  any
+		has   bool
+		num   float64
+		isNum bool
+		text  string
+	}
+	keys := make([]keyed, len(list))
+	for i, item := range list {
+		k := keyed{item: item}
+		var id any
+		id, k.has = lookup(item, idPath)
+		switch v := id.(type) {
+		case json.Number:
+			k.text = v.String()
+			if f, err := strconv.ParseFloat(k.text, 64); err == nil {
+				k.num, k.isNum = f, true
+			}
+		case string:
+			k.text = v
+		default:
+			var buf bytes.Buffer
+			_ = json.NewEncoder(&buf).Encode(v)
+			k.text = buf.String()
+		}
+		keys[i] = k
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch {
+		case a.has != b.has:
+			return a.has
+		case a.isNum && b.isNum && a.num != b.num:
+			return a.num < b.num
+		}
+		return a.text < b.text
+	})
+	for i, k := range keys {
+		list[i] = k.item
+	}
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonstream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func normalize(t *testing.T, doc, listPath, idField string) string {
+	t.Helper()
+	var sb strings.Builder
+	require.NoError(t, Normalize(strings.NewReader(doc), &sb, listPath, idField))
+	return sb.String()
+}
+
+func TestNormalize_SortsKeysAndIndents(t *testing.T) {
+	got := normalize(t, `{"b":1,"a":{"d":[3,1],"c":"<x>"},"n":1.50}`, "", "")
+	assert.Equal(t, `{
+  "a": {
+    "c": "<x>",
+    "d": [
+      3,
+      1
+    ]
+  },
+  "b": 1,
+  "n": 1.50
+}
+`, got, "other arrays keep their order and numbers are unchanged")
+}
+
+func TestNormalize_SortsListByID(t *testing.T) {
+	a := `{"fabrics":[{"id":10,"name":"b"},{"name":"none"},{"id":9,"name":"a"}]}`
+	b := `{"fabrics":[{"name":"a","id":9},{"id":10,"name":"b"},{"name":"none"}]}`
+	got := normalize(t, a, "fabrics", "id")
+	assert.Equal(t, got, normalize(t, b, "fabrics", "id"), "same content, same bytes")
+	assert.Less(t, strings.Index(got, `"a"`), strings.Index(got, `"b"`), "numeric IDs sort numerically")
+	assert.Less(t, strings.Index(got, `"b"`), strings.Index(got, `"none"`), "items without an ID sort last")
+}
+
+func TestNormalize_RootArrayAndNestedID(t *testing.T) {
+	got := normalize(t, `[{"k":{"name":"s2"}},{"k":{"name":"s1"}}]`, "@this", "k.name")
+	assert.Less(t, strings.Index(got, "s1"), strings.Index(got, "s2"))
+}
+
+func TestNormalize_Empty(t *testing.T) {
+	assert.Equal(t, "", normalize(t, "", "", "id"))
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	var sb strings.Builder
+	assert.Error(t, Normalize(strings.NewReader(`{"a":`), &sb, "", ""))
+	assert.Error(t, Normalize(strings.NewReader(`{} {}`), &sb, "", ""))
+	assert.Error(t, Normalize(strings.NewReader(`<html>`), &sb, "", ""))
+}
@@ -0,0 +1,187 @@
+// Package log configures the collector's logger: console or JSON output to
+// stderr and a log file, at a configurable level.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+)
+
+// logFile receives a copy of everything logged to stderr.
+const logFile = "ndfc-collector.log"
+
+// Logger aliases the zerolog.Logger.
+type Logger = zerolog.Logger
+
+// Level aliases the zerolog.Level.
+type Level = zerolog.Level
+
+// Levels, from most to least verbose.
+var (
+	TraceLevel = zerolog.TraceLevel
+	DebugLevel = zerolog.DebugLevel
+	InfoLevel  = zerolog.InfoLevel
+	WarnLevel  = zerolog.WarnLevel
+	ErrorLevel = zerolog.ErrorLevel
+)
+
+// Output formats.
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+var (
+	mu     sync.Mutex
+	format = FormatConsole
+	out    io.Writer // stderr and the log file, once opened
+	logger *Logger
+)
+
+func init() {
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	zerolog.DurationFieldInteger = true
+}
+
+// SetLevel sets the minimum level logged.
+func SetLevel(level Level) {
+	zerolog.SetGlobalLevel(level)
+}
+
+// GetLevel returns the minimum level logged.
+func GetLevel() Level {
+	return zerolog.GlobalLevel()
+}
+
+// ParseLevel converts a level name (trace, debug, info, warn or error).
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "trace":
+		return TraceLevel, nil
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// SetFormat selects console (human-readable, the default) or JSON output,
+// one object per line. It must be called before anything is logged.
+func SetFormat(f string) error {
+	switch f {
+	case "", FormatConsole:
+		f = FormatConsole
+	case FormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q", f)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	format = f
+	logger = nil
+	return nil
+}
+
+// New returns the shared logger, creating the log file on first use.
+func New() Logger {
+	if testing.Testing() {
+		return zerolog.Nop()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if logger == nil {
+		l := newLogger(output(), format)
+		logger = &l
+	}
+	return *logger
+}
+
+// Tee returns a logger writing to the same outputs as New and also to w,
+// as JSON lines whatever the format, e.g. to keep the log of one job.
+func Tee(w io.Writer) Logger {
+	if testing.Testing() {
+		return newLogger(w, FormatJSON)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return zerolog.New(io.MultiWriter(formatWriter(output(), format), w)).
+		With().Timestamp().Logger()
+}
+
+// output returns stderr and the log file, opening the file on first use.
+// mu must be held.
+func output() io.Writer {
+	if out == nil {
+		out = os.Stderr
+		if file, err := os.Create(logFile); err == nil {
+			out = io.MultiWriter(os.Stderr, file)
+		}
+	}
+	return out
+}
+
+// newLogger returns a logger writing to out in the given format.
+func newLogger(out io.Writer, format string) Logger {
+	return zerolog.New(formatWriter(out, format)).With().Timestamp().Logger()
+}
+
+// formatWriter wraps out to render the JSON written by zerolog in the given
+// format.
+func formatWriter(out io.Writer, format string) io.Writer {
+	if format == FormatJSON {
+		return out
+	}
+	return zerolog.ConsoleWriter{
+		Out:     out,
+		NoColor: runtime.GOOS == "windows",
+	}
+}
+
+// Trace starts a trace level message.
+func Trace() *zerolog.Event { l := New(); return l.Trace() }
+
+// Debug starts a debug level message.
+func Debug() *zerolog.Event { l := New(); return l.Debug() }
+
+// Info starts an info level message.
+func Info() *zerolog.Event { l := New(); return l.Info() }
+
+// Warn starts a warning level message.
+func Warn() *zerolog.Event { l := New(); return l.Warn() }
+
+// Error starts an error level message.
+func Error() *zerolog.Event { l := New(); return l.Error() }
+
+// Fatal starts a message that exits the program once sent.
+func Fatal() *zerolog.Event { l := New(); return l.Fatal() }
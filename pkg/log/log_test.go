@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]Level{
+		"trace":   TraceLevel,
+		"debug":   DebugLevel,
+		"INFO":    InfoLevel,
+		"warn":    WarnLevel,
+		"warning": WarnLevel,
+		"error":   ErrorLevel,
+	} {
+		level, err := ParseLevel(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, level, name)
+	}
+	_, err := ParseLevel("loud")
+	assert.Error(t, err)
+}
+
+func TestSetFormat(t *testing.T) {
+	defer SetFormat(FormatConsole)
+	assert.NoError(t, SetFormat(FormatJSON))
+	assert.Equal(t, FormatJSON, format)
+	assert.NoError(t, SetFormat(""))
+	assert.Equal(t, FormatConsole, format)
+	assert.Error(t, SetFormat("xml"))
+}
+
+func TestNewLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(&buf, FormatJSON)
+	l.Info().
+		Uint64("request_id", 7).
+		Str("db_key", "inventory/switches").
+		Dur("duration", 1500*time.Millisecond).
+		Msg("done")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "info", line["level"])
+	assert.Equal(t, "done", line["message"])
+	assert.Equal(t, float64(7), line["request_id"])
+	assert.Equal(t, "inventory/switches", line["db_key"])
+	assert.Equal(t, float64(1500), line["duration"])
+	assert.Contains(t, line, "time")
+}
+
+func TestNewLoggerConsole(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(&buf, FormatConsole)
+	l.Warn().Str("db_key", "inventory/switches").Msg("retrying")
+	assert.Contains(t, buf.String(), "retrying")
+	assert.Contains(t, buf.String(), "db_key=")
+	assert.NotContains(t, buf.String(), "{")
+}
+
+func TestTee(t *testing.T) {
+	var buf bytes.Buffer
+	l := Tee(&buf)
+	l.Info().Str("job", "j1").Msg("started")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "j1", line["job"])
+	assert.Equal(t, "started", line["message"])
+}
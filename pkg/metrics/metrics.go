@@ -0,0 +1,225 @@
+// Package metrics measures collection runs and exposes the measurements in
+// the Prometheus text format and as a run summary.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetaKey is the manifest metadata key holding the run summary.
+const MetaKey = "metrics"
+
+// buckets are the upper bounds, in seconds, of the request latency
+// histogram.
+var buckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Endpoint holds the measurements of one request template.
+type Endpoint struct {
+	Template   string  `json:"template"` // db_key template, or URL when there is none
+	Requests   int     `json:"requests"`
+	Failures   int     `json:"failures"`
+	Retries    int     `json:"retries"`
+	Carried    int     `json:"carried"` // responses copied from the baseline instead
+	Bytes      int64   `json:"bytes"`
+	Seconds    float64 `json:"seconds"`     // total request time, including retries
+	MaxSeconds float64 `json:"max_seconds"` // slowest single request
+
+	buckets []uint64 // cumulative counts per bucket
+}
+
+// Level holds the measurements of one dependency level.
+type Level struct {
+	Level    int     `json:"level"`
+	Requests int     `json:"requests"`
+	Seconds  float64 `json:"seconds"`
+}
+
+// Metrics accumulates measurements for a collection run. It is safe for
+// concurrent use, and serves the Prometheus text format over HTTP.
+type Metrics struct {
+	mu        sync.Mutex
+	start     time.Time
+	endpoints map[string]*Endpoint
+	levels    []Level
+}
+
+// New returns empty metrics for a run starting now.
+func New() *Metrics {
+	return &Metrics{start: time.Now(), endpoints: map[string]*Endpoint{}}
+}
+
+func (m *Metrics) endpoint(template string) *Endpoint {
+	e, ok := m.endpoints[template]
+	if !ok {
+		e = &Endpoint{Template: template, buckets: make([]uint64, len(buckets))}
+		m.endpoints[template] = e
+	}
+	return e
+}
+
+// Request records a finished request for template that took d, including
+// retries, and returned size bytes. err is the final error, if any.
+func (m *Metrics) Request(template string, d time.Duration, size int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.endpoint(template)
+	e.Requests++
+	if err != nil {
+		e.Failures++
+	}
+	e.Bytes += size
+	s := d.Seconds()
+	e.Seconds += s
+	e.MaxSeconds = max(e.MaxSeconds, s)
+	for i, le := range buckets {
+		if s <= le {
+			e.buckets[i]++
+		}
+	}
+}
+
+// Retry records a retried request for template.
+func (m *Metrics) Retry(template string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endpoint(template).Retries++
+}
+
+// Carry records a response for template that was carried forward from the
+// baseline archive unchanged. A conditional request that found it
+// unchanged is recorded separately with Request.
+func (m *Metrics) Carry(template string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endpoint(template).Carried++
+}
+
+// Level records a dependency level of n requests that took d.
+func (m *Metrics) Level(level, n int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.levels = append(m.levels, Level{Level: level, Requests: n, Seconds: d.Seconds()})
+}
+
+// Summary is a snapshot of the measurements of a run.
+type Summary struct {
+	Seconds   float64    `json:"seconds"`
+	Requests  int        `json:"requests"`
+	Failures  int        `json:"failures"`
+	Retries   int        `json:"retries"`
+	Carried   int        `json:"carried"`
+	Bytes     int64      `json:"bytes"`
+	Levels    []Level    `json:"levels"`
+	Endpoints []Endpoint `json:"endpoints"` // slowest total time first
+}
+
+// Summary returns the measurements so far.
+func (m *Metrics) Summary() Summary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := Summary{
+		Seconds:   time.Since(m.start).Seconds(),
+		Levels:    append([]Level{}, m.levels...),
+		Endpoints: []Endpoint{},
+	}
+	for _, e := range m.endpoints {
+		s.Requests += e.Requests
+		s.Failures += e.Failures
+		s.Retries += e.Retries
+		s.Carried += e.Carried
+		s.Bytes += e.Bytes
+		ec := *e
+		ec.buckets = append([]uint64(nil), e.buckets...)
+		s.Endpoints = append(s.Endpoints, ec)
+	}
+	sort.Slice(s.Endpoints, func(i, j int) bool {
+		if s.Endpoints[i].Seconds != s.Endpoints[j].Seconds {
+			return s.Endpoints[i].Seconds > s.Endpoints[j].Seconds
+		}
+		return s.Endpoints[i].Template < s.Endpoints[j].Template
+	})
+	return s
+}
+
+// labelEscaper escapes Prometheus label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteText writes the measurements in the Prometheus text exposition
+// format.
+func (m *Metrics) WriteText(w io.Writer) error {
+	s := m.Summary()
+	sort.Slice(s.Endpoints, func(i, j int) bool { return s.Endpoints[i].Template < s.Endpoints[j].Template })
+
+	var b strings.Builder
+	header := func(name, typ, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	label := func(e Endpoint) string {
+		return `template="` + labelEscaper.Replace(e.Template) + `"`
+	}
+
+	header("ndfc_collector_requests_total", "counter", "Requests completed, by db_key template and outcome.")
+	for _, e := range s.Endpoints {
+		fmt.Fprintf(&b, "ndfc_collector_requests_total{%s,outcome=\"ok\"} %d\n", label(e), e.Requests-e.Failures)
+		fmt.Fprintf(&b, "ndfc_collector_requests_total{%s,outcome=\"error\"} %d\n", label(e), e.Failures)
+	}
+	header("ndfc_collector_retries_total", "counter", "Request retries, by db_key template.")
+	for _, e := range s.Endpoints {
+		fmt.Fprintf(&b, "ndfc_collector_retries_total{%s} %d\n", label(e), e.Retries)
+	}
+	header("ndfc_collector_carried_total", "counter", "Responses carried forward from the baseline archive, by db_key template.")
+	for _, e := range s.Endpoints {
+		fmt.Fprintf(&b, "ndfc_collector_carried_total{%s} %d\n", label(e), e.Carried)
+	}
+	header("ndfc_collector_response_bytes_total", "counter", "Response bytes received, by db_key template.")
+	for _, e := range s.Endpoints {
+		fmt.Fprintf(&b, "ndfc_collector_response_bytes_total{%s} %d\n", label(e), e.Bytes)
+	}
+	header("ndfc_collector_request_duration_seconds", "histogram", "Request latency including retries, by db_key template.")
+	for _, e := range s.Endpoints {
+		for i, le := range buckets {
+			fmt.Fprintf(&b, "ndfc_collector_request_duration_seconds_bucket{%s,le=\"%g\"} %d\n", label(e), le, e.buckets[i])
+		}
+		fmt.Fprintf(&b, "ndfc_collector_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", label(e), e.Requests)
+		fmt.Fprintf(&b, "ndfc_collector_request_duration_seconds_sum{%s} %g\n", label(e), e.Seconds)
+		fmt.Fprintf(&b, "ndfc_collector_request_duration_seconds_count{%s} %d\n", label(e), e.Requests)
+	}
+	header("ndfc_collector_level_duration_seconds", "gauge", "Duration of each finished dependency level.")
+	for _, l := range s.Levels {
+		fmt.Fprintf(&b, "ndfc_collector_level_duration_seconds{level=\"%d\"} %g\n", l.Level, l.Seconds)
+	}
+	header("ndfc_collector_run_duration_seconds", "gauge", "Time since the collection started.")
+	fmt.Fprintf(&b, "ndfc_collector_run_duration_seconds %g\n", s.Seconds)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP serves the measurements in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteText(w)
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetrics() *Metrics {
+	m := New()
+	m.Request("inventory/switches", 3*time.Second, 2000, nil)
+	m.Retry("fabrics/{fabricName}/vrfs")
+	m.Request("fabrics/{fabricName}/vrfs", 200*time.Millisecond, 100, nil)
+	m.Request("fabrics/{fabricName}/vrfs", 400*time.Millisecond, 0, errors.New("HTTP 500"))
+	m.Level(0, 1, 3*time.Second)
+	m.Level(1, 2, 500*time.Millisecond)
+	return m
+}
+
+func TestSummary(t *testing.T) {
+	s := testMetrics().Summary()
+	assert.Equal(t, 3, s.Requests)
+	assert.Equal(t, 1, s.Failures)
+	assert.Equal(t, 1, s.Retries)
+	assert.Equal(t, int64(2100), s.Bytes)
+	assert.Equal(t, []Level{{0, 1, 3}, {1, 2, 0.5}}, s.Levels)
+	require.Len(t, s.Endpoints, 2)
+	assert.Equal(t, "inventory/switches", s.Endpoints[0].Template, "slowest first")
+	vrfs := s.Endpoints[1]
+	assert.Equal(t, 2, vrfs.Requests)
+	assert.Equal(t, 1, vrfs.Failures)
+	assert.InDelta(t, 0.6, vrfs.Seconds, 1e-9)
+	assert.InDelta(t, 0.4, vrfs.MaxSeconds, 1e-9)
+}
+
+func TestWriteText(t *testing.T) {
+	rec := httptest.NewRecorder()
+	testMetrics().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	out := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE ndfc_collector_requests_total counter",
+		`ndfc_collector_requests_total{template="fabrics/{fabricName}/vrfs",outcome="ok"} 1`,
+		`ndfc_collector_requests_total{template="fabrics/{fabricName}/vrfs",outcome="error"} 1`,
+		`ndfc_collector_retries_total{template="fabrics/{fabricName}/vrfs"} 1`,
+		`ndfc_collector_response_bytes_total{template="inventory/switches"} 2000`,
+		`ndfc_collector_request_duration_seconds_bucket{template="fabrics/{fabricName}/vrfs",le="0.25"} 1`,
+		`ndfc_collector_request_duration_seconds_bucket{template="fabrics/{fabricName}/vrfs",le="0.5"} 2`,
+		`ndfc_collector_request_duration_seconds_bucket{template="inventory/switches",le="+Inf"} 1`,
+		`ndfc_collector_request_duration_seconds_count{template="inventory/switches"} 1`,
+		`ndfc_collector_level_duration_seconds{level="1"} 0.5`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+
+	m := New()
+	m.Request(`a"b`, time.Second, 0, nil)
+	var sb strings.Builder
+	require.NoError(t, m.WriteText(&sb))
+	assert.Contains(t, sb.String(), `{template="a\"b",outcome="ok"} 1`)
+}
+
+func TestWriteTable(t *testing.T) {
+	var sb strings.Builder
+	require.NoError(t, testMetrics().Summary().WriteTable(&sb, 1))
+	out := sb.String()
+	assert.Regexp(t, `^Collected 3 responses \(2\.1 kB\) in \S+: 1 failed, 1 retries\.\n`, out)
+	assert.Regexp(t, `\ninventory/switches\s+1\s+0\s+0\s+3s\s+3s\s+2\.0 kB\n`, out)
+	assert.Contains(t, out, "\nFAILED ENDPOINT", "endpoints with failures beyond the top rows are listed")
+	assert.Regexp(t, `\nfabrics/\{fabricName\}/vrfs\s+2\s+1\s+1\s+600ms\s+400ms\s+100 B\n`, out)
+}
+
+func TestCarry(t *testing.T) {
+	m := testMetrics()
+	m.Carry("fabrics/{fabricName}/vrfs")
+	m.Carry("fabrics/{fabricName}/networks")
+	s := m.Summary()
+	assert.Equal(t, 2, s.Carried)
+	assert.Equal(t, 3, s.Requests, "carried responses are not requests")
+
+	var sb strings.Builder
+	require.NoError(t, m.WriteText(&sb))
+	assert.Contains(t, sb.String(), `ndfc_collector_carried_total{template="fabrics/{fabricName}/networks"} 1`+"\n")
+
+	sb.Reset()
+	require.NoError(t, s.WriteTable(&sb, 1))
+	assert.Contains(t, sb.String(), "\nCarried forward 2 unchanged responses from the baseline.\n")
+}
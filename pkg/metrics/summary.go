@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteTable writes a human-readable summary: totals, level durations and
+// the top slowest endpoints by total time, followed by every endpoint with
+// failures.
+func (s Summary) WriteTable(w io.Writer, top int) error {
+	fmt.Fprintf(w, "Collected %d responses (%s) in %s: %d failed, %d retries.\n",
+		s.Requests, formatBytes(s.Bytes), formatSeconds(s.Seconds), s.Failures, s.Retries)
+	if s.Carried > 0 {
+		fmt.Fprintf(w, "Carried forward %d unchanged responses from the baseline.\n", s.Carried)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(s.Levels) > 0 {
+		fmt.Fprintln(tw, "\nLEVEL\tREQUESTS\tDURATION")
+		for _, l := range s.Levels {
+			fmt.Fprintf(tw, "%d\t%d\t%s\n", l.Level, l.Requests, formatSeconds(l.Seconds))
+		}
+	}
+
+	row := func(e Endpoint) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n", e.Template, e.Requests, e.Failures, e.Retries,
+			formatSeconds(e.Seconds), formatSeconds(e.MaxSeconds), formatBytes(e.Bytes))
+	}
+	header := "\nENDPOINT\tREQUESTS\tFAILED\tRETRIES\tTOTAL\tMAX\tSIZE"
+	if len(s.Endpoints) > 0 {
+		fmt.Fprintln(tw, header)
+		n := min(top, len(s.Endpoints))
+		for _, e := range s.Endpoints[:n] {
+			row(e)
+		}
+		var failed []Endpoint
+		for _, e := range s.Endpoints[n:] {
+			if e.Failures > 0 {
+				failed = append(failed, e)
+			}
+		}
+		if len(failed) > 0 {
+			fmt.Fprintln(tw, "\nFAILED "+header[1:])
+			for _, e := range failed {
+				row(e)
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// formatSeconds rounds a duration in seconds for display.
+func formatSeconds(s float64) string {
+	d := time.Duration(s * float64(time.Second))
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// formatBytes renders a byte count with a decimal unit.
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ndfc
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// CacheMetaKey is the archive manifest meta key of the cache statistics.
+const CacheMetaKey = "cache"
+
+// Cache is an on-disk HTTP cache of GET responses that NDFC sent with an
+// ETag or Last-Modified header. With a cache, Stream makes requests for
+// cached resources conditional and serves the cached body when NDFC answers
+// 304. Each entry is a file named after the hash of the user and URL,
+// holding a JSON header line followed by the body. Entries not used for the
+// cache's maximum age are removed when it is opened.
+type Cache struct {
+	dir    string
+	hits   atomic.Int64
+	misses atomic.Int64
+	stored atomic.Int64
+	saved  atomic.Int64
+}
+
+// CacheStats counts how a run used the cache.
+type CacheStats struct {
+	Hits       int64 `json:"hits"`        // responses served from the cache on 304
+	Misses     int64 `json:"misses"`      // responses downloaded in full
+	Stored     int64 `json:"stored"`      // responses added to or replaced in the cache
+	BytesSaved int64 `json:"bytes_saved"` // bytes served from the cache
+}
+
+// cacheEntry is the header line of a cache file.
+type cacheEntry struct {
+	URL string `json:"url"`
+	Validators
+	Stored time.Time `json:"stored"`
+}
+
+// OpenCache opens the cache in dir, creating the directory if needed, and
+// removes the entries not stored or used within maxAge, unless maxAge is
+// zero. The directory is private to the user, since it holds controller
+// data.
+func OpenCache(dir string, maxAge time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cannot create cache directory: %w", err)
+	}
+	c := &Cache{dir: dir}
+	if maxAge > 0 {
+		if err := c.prune(time.Now().Add(-maxAge)); err != nil {
+			return nil, fmt.Errorf("cannot prune cache directory: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// prune removes the entries, and leftover partial entries, last stored or
+// used before cutoff.
+func (c *Cache) prune(cutoff time.Time) error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(c.dir, f.Name()))
+		}
+	}
+	return nil
+}
+
+// DefaultCacheDir returns the cache directory used when none is configured,
+// under the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ndfc-collector"), nil
+}
+
+// UseCache makes the client cache responses in c.
+func UseCache(c *Cache) func(*Client) {
+	return func(client *Client) {
+		client.cache = c
+	}
+}
+
+// Stats returns the cache statistics so far.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		Stored:     c.stored.Load(),
+		BytesSaved: c.saved.Load(),
+	}
+}
+
+// path returns the file of the entry for url as requested by usr, since
+// NDFC shows users only what their roles allow.
+func (c *Cache) path(usr, url string) string {
+	sum := sha256.Sum256([]byte(usr + "\n" + url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// open returns the entry for url and a reader of its body, which the caller
+// must close. A missing or unreadable entry is reported as not found.
+func (c *Cache) open(usr, url string) (cacheEntry, io.ReadCloser, bool) {
+	f, err := os.Open(c.path(usr, url))
+	if err != nil {
+		return cacheEntry{}, nil, false
+	}
+	r := bufio.NewReader(f)
+	line, err := r.ReadBytes('\n')
+	var entry cacheEntry
+	if err == nil {
+		err = json.Unmarshal(line, &entry)
+	}
+	if err != nil || entry.URL != url || entry.IsZero() {
+		f.Close()
+		return cacheEntry{}, nil, false
+	}
+	return entry, struct {
+		io.Reader
+		io.Closer
+	}{r, f}, true
+}
+
+// create starts a new entry for url, to be filled with the body and
+// committed. It replaces the current entry only once committed.
+func (c *Cache) create(usr, url string, v Validators) (*pendingEntry, error) {
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(cacheEntry{URL: url, Validators: v, Stored: time.Now().UTC()})
+	if err == nil {
+		_, err = f.Write(append(line, '\n'))
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &pendingEntry{f: f, path: c.path(usr, url)}, nil
+}
+
+// touch marks the entry for url as used, so that it is not pruned.
+func (c *Cache) touch(usr, url string) {
+	now := time.Now()
+	os.Chtimes(c.path(usr, url), now, now)
+}
+
+// remove drops the entry for url, e.g. when NDFC stopped sending validators.
+func (c *Cache) remove(usr, url string) {
+	os.Remove(c.path(usr, url))
+}
+
+// pendingEntry is a cache entry being written. Write errors are kept for
+// commit rather than returned, so that a full disk fails the cache entry
+// but not the request.
+type pendingEntry struct {
+	f    *os.File
+	path string
+	err  error
+}
+
+func (p *pendingEntry) Write(b []byte) (int, error) {
+	if p.err == nil {
+		_, p.err = p.f.Write(b)
+	}
+	return len(b), nil
+}
+
+// commit replaces the entry with the written one.
+func (p *pendingEntry) commit() error {
+	err := p.f.Close()
+	if p.err != nil {
+		err = p.err
+	}
+	if err == nil {
+		err = os.Rename(p.f.Name(), p.path)
+	}
+	if err != nil {
+		os.Remove(p.f.Name())
+	}
+	return err
+}
+
+// discard drops the written entry.
+func (p *pendingEntry) discard() {
+	p.f.Close()
+	os.Remove(p.f.Name())
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ndfc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream_Cache(t *testing.T) {
+	var mu sync.Mutex
+	etag, body := `"v1"`, `{"fabrics":["f1"]}`
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, r.Header.Get("If-None-Match"))
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	cache, err := OpenCache(t.TempDir(), 0)
+	require.NoError(t, err)
+	client, err := NewClient(srv.URL, "admin", "secret", UseCache(cache))
+	require.NoError(t, err)
+
+	get := func(mods ...func(*Req)) string {
+		var buf bytes.Buffer
+		_, err := client.GetStream("/fabrics", &buf, append(mods, Query("page", "1"))...)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	assert.Equal(t, body, get(), "first request downloads and stores")
+	var v Validators
+	assert.Equal(t, body, get(SaveValidators(&v)), "unchanged response served from the cache")
+	assert.Equal(t, `"v1"`, v.ETag)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Stored: 1, BytesSaved: int64(len(body))}, cache.Stats())
+
+	mu.Lock()
+	etag, body = `"v2"`, `{"fabrics":["f1","f2"]}`
+	mu.Unlock()
+	assert.Equal(t, `{"fabrics":["f1","f2"]}`, get(), "changed response replaces the entry")
+
+	// A request the caller made conditional bypasses the cache.
+	var buf bytes.Buffer
+	_, err = client.GetStream("/fabrics", &buf, Query("page", "1"), IfChanged(Validators{ETag: `"v2"`}))
+	assert.Error(t, err)
+	assert.Empty(t, buf.String())
+
+	// Responses without validators are not cached.
+	mu.Lock()
+	etag, body = "", `{"fabrics":[]}`
+	mu.Unlock()
+	assert.Equal(t, `{"fabrics":[]}`, get())
+	assert.Equal(t, `{"fabrics":[]}`, get())
+	files, err := os.ReadDir(cache.dir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "entry dropped once NDFC stops sending validators")
+
+	assert.Equal(t, []string{"", `"v1"`, `"v1"`, `"v2"`, `"v2"`, ""}, sent)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 4, Stored: 2, BytesSaved: int64(len(`{"fabrics":["f1"]}`))}, cache.Stats())
+}
+
+func TestCache_PerUser(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	cache, err := OpenCache(t.TempDir(), 0)
+	require.NoError(t, err)
+	admin, err := NewClient(srv.URL, "admin", "secret", UseCache(cache))
+	require.NoError(t, err)
+	viewer, err := NewClient(srv.URL, "viewer", "secret", UseCache(cache))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = admin.GetStream("/fabrics", &buf)
+	require.NoError(t, err)
+	_, err = viewer.GetStream("/fabrics", &buf)
+	require.NoError(t, err)
+	stats, ok := viewer.CacheStats()
+	require.True(t, ok)
+	assert.Zero(t, stats.Hits, "entries are not shared between users")
+
+	_, ok = (&Client{}).CacheStats()
+	assert.False(t, ok)
+}
+
+func TestOpenCache_Prune(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"stale", ".tmp-stale"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o600))
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fresh"), []byte("{}\n"), 0o600))
+
+	_, err := OpenCache(dir, 0)
+	require.NoError(t, err)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 3, "no pruning without a maximum age")
+
+	_, err = OpenCache(dir, 24*time.Hour)
+	require.NoError(t, err)
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "fresh", files[0].Name())
+}
@@ -26,7 +26,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/brightpuddle/gobits/log"
+	"ndfc-collector/pkg/log"
 
 	"github.com/tidwall/gjson"
 )
@@ -47,6 +47,13 @@ type Client struct {
 	LastRefresh time.Time
 	// Token is the current authentication token (not used in NDFC, uses session cookies)
 	Token string
+	// Version is the controller release, or zero if it is not known.
+	Version Version
+	// limiter throttles outgoing requests when rate limiting is enabled.
+	// It is a pointer so that copies of the client share one bucket.
+	limiter *RateLimiter
+	// cache stores responses for conditional requests, if enabled.
+	cache *Cache
 }
 
 // NewClient creates a new NDFC HTTP client.
@@ -105,13 +112,53 @@ func RequestTimeout(x time.Duration) func(*Client) {
 	}
 }
 
+// RateLimit limits the client to rps requests per second with bursts of up to
+// burst requests, independent of how many requests are in flight.
+func RateLimit(rps float64, burst int) func(*Client) {
+	return func(client *Client) {
+		client.limiter = NewRateLimiter(rps, burst, false)
+	}
+}
+
+// AdaptiveRateLimit is like RateLimit, but backs off automatically when NDFC
+// reports 429/5xx errors or latency climbs, and speeds back up to maxRPS once
+// the controller recovers. A maxRPS of zero uses a default ceiling.
+func AdaptiveRateLimit(maxRPS float64, burst int) func(*Client) {
+	return func(client *Client) {
+		client.limiter = NewRateLimiter(maxRPS, burst, true)
+	}
+}
+
+// send makes a request, applying rate limiting when enabled.
+// The caller is responsible for closing the response body.
+func (client *Client) send(req Req) (*http.Response, error) {
+	if client.limiter != nil {
+		client.limiter.Wait()
+	}
+	start := time.Now()
+	httpRes, err := client.HTTPClient.Do(req.HTTPReq)
+	if client.limiter != nil {
+		client.limiter.Observe(time.Since(start), httpRes, err)
+	}
+	return httpRes, err
+}
+
+// StatusError is returned when NDFC answers with a status other than 200.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received HTTP status %d", e.Code)
+}
+
 // Do makes a request.
 // Requests for Do are built outside of the client, e.g.
 //
 //	req := client.NewReq("GET", "/api/v1/manage/fabrics", nil)
 //	res := client.Do(req)
 func (client *Client) Do(req Req) (Res, error) {
-	httpRes, err := client.HTTPClient.Do(req.HTTPReq)
+	httpRes, err := client.send(req)
 	if err != nil {
 		return Res{}, err
 	}
@@ -125,12 +172,119 @@ func (client *Client) Do(req Req) (Res, error) {
 	res := Res(gjson.ParseBytes(body))
 
 	if httpRes.StatusCode != http.StatusOK {
-		return Res{}, fmt.Errorf("received HTTP status %d", httpRes.StatusCode)
+		return Res{}, &StatusError{Code: httpRes.StatusCode}
 	}
 
 	return res, nil
 }
 
+// Stream makes a request and copies the response body to w as it arrives,
+// without buffering it in memory. Nothing is written unless NDFC answers
+// with HTTP 200. It returns the number of bytes written. The validators of
+// the response, including a 304, are saved when asked for with
+// SaveValidators.
+//
+// With a cache, a GET request that is not already conditional is made
+// conditional on the cached response, if any, and a 304 is answered from
+// the cache as if NDFC had sent the body again.
+func (client *Client) Stream(req Req, w io.Writer) (int64, error) {
+	cache := client.cacheFor(req)
+	url := req.HTTPReq.URL.String()
+	var entry cacheEntry
+	var cached io.ReadCloser
+	if cache != nil {
+		var ok bool
+		if entry, cached, ok = cache.open(client.Usr, url); ok {
+			defer cached.Close()
+			IfChanged(entry.Validators)(&req)
+		}
+	}
+
+	httpRes, err := client.send(req)
+	if err != nil {
+		return 0, err
+	}
+	defer httpRes.Body.Close()
+
+	v := Validators{
+		ETag:         httpRes.Header.Get("ETag"),
+		LastModified: httpRes.Header.Get("Last-Modified"),
+	}
+	if httpRes.StatusCode == http.StatusNotModified && cached != nil {
+		if v.IsZero() {
+			v = entry.Validators
+		}
+		if req.validators != nil {
+			*req.validators = v
+		}
+		cache.hits.Add(1)
+		cache.touch(client.Usr, url)
+		n, err := io.Copy(w, cached)
+		cache.saved.Add(n)
+		if err != nil {
+			return n, fmt.Errorf("cannot read cached response: %w", err)
+		}
+		return n, nil
+	}
+	if req.validators != nil {
+		*req.validators = v
+	}
+	if httpRes.StatusCode != http.StatusOK {
+		return 0, &StatusError{Code: httpRes.StatusCode}
+	}
+
+	var pending *pendingEntry
+	if cache != nil {
+		cache.misses.Add(1)
+		if v.IsZero() {
+			cache.remove(client.Usr, url)
+		} else if pending, err = cache.create(client.Usr, url, v); err != nil {
+			log.Debug().Err(err).Msgf("Cannot cache %s.", url)
+		}
+	}
+	if pending == nil {
+		n, err := io.Copy(w, httpRes.Body)
+		if err != nil {
+			return n, fmt.Errorf("cannot read response body: %w", err)
+		}
+		return n, nil
+	}
+
+	n, err := io.Copy(io.MultiWriter(w, pending), httpRes.Body)
+	if err != nil {
+		pending.discard()
+		return n, fmt.Errorf("cannot read response body: %w", err)
+	}
+	if err := pending.commit(); err != nil {
+		log.Debug().Err(err).Msgf("Cannot cache %s.", url)
+	} else {
+		cache.stored.Add(1)
+	}
+	return n, nil
+}
+
+// cacheFor returns the cache to use for req, or nil. Only GET requests are
+// cached, and requests made conditional by the caller are left alone.
+func (client *Client) cacheFor(req Req) *Cache {
+	if client.cache == nil || req.HTTPReq.Method != http.MethodGet {
+		return nil
+	}
+	h := req.HTTPReq.Header
+	if h.Get("If-None-Match") != "" || h.Get("If-Modified-Since") != "" {
+		return nil
+	}
+	return client.cache
+}
+
+// CacheStats returns the statistics of the client's cache, and false if the
+// client has none.
+func (client *Client) CacheStats() (CacheStats, bool) {
+	if client.cache == nil {
+		return CacheStats{}, false
+	}
+	return client.cache.Stats(), true
+}
+
 // Get makes a GET request and returns a GJSON result.
 // Results will be the raw JSON response from NDFC
 func (client *Client) Get(path string, mods ...func(*Req)) (Res, error) {
@@ -139,6 +293,12 @@ func (client *Client) Get(path string, mods ...func(*Req)) (Res, error) {
 	return res, err
 }
 
+// GetStream makes a GET request and streams the raw response body to w.
+func (client *Client) GetStream(path string, w io.Writer, mods ...func(*Req)) (int64, error) {
+	req := client.NewReq("GET", path, nil, mods...)
+	return client.Stream(req, w)
+}
+
 // Post makes a POST request and returns a GJSON result.
 func (client *Client) Post(path, data string, mods ...func(*Req)) (Res, error) {
 	req := client.NewReq("POST", path, strings.NewReader(data), mods...)
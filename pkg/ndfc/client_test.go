@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ndfc
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream_Conditional(t *testing.T) {
+	const etag = `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Mon, 19 Oct 2026 02:00:00 GMT")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"fabrics":[]}`))
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.URL, "admin", "secret")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	var v Validators
+	_, err = client.GetStream("/fabrics", &buf, SaveValidators(&v))
+	require.NoError(t, err)
+	assert.Equal(t, `{"fabrics":[]}`, buf.String())
+	assert.Equal(t, Validators{ETag: etag, LastModified: "Mon, 19 Oct 2026 02:00:00 GMT"}, v)
+
+	buf.Reset()
+	var again Validators
+	_, err = client.GetStream("/fabrics", &buf, IfChanged(v), SaveValidators(&again))
+	var statusErr *StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotModified, statusErr.Code)
+	assert.Empty(t, buf.String())
+	assert.Equal(t, v, again)
+	assert.True(t, Validators{}.IsZero())
+}
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ndfc
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"ndfc-collector/pkg/log"
+)
+
+const (
+	// defaultAdaptiveRate is the ceiling used by the adaptive limiter when no
+	// explicit rate is configured.
+	defaultAdaptiveRate = 10.0
+	// minAdaptiveRate is the floor the adaptive limiter will never go below.
+	minAdaptiveRate = 0.2
+	// recoverAfter is the number of consecutive healthy responses required
+	// before the adaptive limiter raises the rate again.
+	recoverAfter = 10
+	// slowFactor is how far above the baseline latency a response must be
+	// before it is considered a sign of controller distress.
+	slowFactor = 3.0
+)
+
+// RateLimiter is a token-bucket rate limiter shared by all copies of a Client.
+// Tokens refill at rate per second up to burst; each request consumes one.
+//
+// In adaptive mode the rate is lowered multiplicatively whenever NDFC answers
+// with 429 or 5xx, a request fails outright, or latency climbs well above the
+// observed baseline, and raised additively back towards the configured
+// ceiling once the controller responds normally again.
+type RateLimiter struct {
+	mu        sync.Mutex
+	rate      float64   // current tokens per second
+	maxRate   float64   // configured ceiling
+	burst     float64   // bucket capacity
+	tokens    float64   // available tokens; negative means reserved debt
+	last      time.Time // last refill
+	notBefore time.Time // set from Retry-After on 429 responses
+	adaptive  bool
+	healthy   int           // consecutive healthy responses
+	baseline  time.Duration // smoothed latency of healthy responses
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewRateLimiter creates a token-bucket limiter allowing rps requests per
+// second with bursts of up to burst requests.
+func NewRateLimiter(rps float64, burst int, adaptive bool) *RateLimiter {
+	if rps <= 0 {
+		rps = defaultAdaptiveRate
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:     rps,
+		maxRate:  rps,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		adaptive: adaptive,
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+// Rate returns the current request rate in requests per second.
+func (l *RateLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Wait blocks until a request may be sent.
+func (l *RateLimiter) Wait() {
+	l.mu.Lock()
+	now := l.now()
+	l.refill(now)
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if hold := l.notBefore.Sub(now); hold > delay {
+		delay = hold
+	}
+	l.mu.Unlock()
+
+	if delay > 0 {
+		l.sleep(delay)
+	}
+}
+
+// Observe feeds the outcome of a request back into the limiter.
+// It is a no-op unless the limiter is adaptive, except that a Retry-After
+// header on a 429 response is always honoured.
+func (l *RateLimiter) Observe(latency time.Duration, res *http.Response, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := 0
+	if res != nil {
+		status = res.StatusCode
+	}
+	if status == http.StatusTooManyRequests {
+		if secs, perr := strconv.Atoi(res.Header.Get("Retry-After")); perr == nil && secs > 0 {
+			l.notBefore = l.now().Add(time.Duration(secs) * time.Second)
+		}
+	}
+	if !l.adaptive {
+		return
+	}
+
+	switch {
+	case err != nil || status == http.StatusTooManyRequests || status >= 500:
+		l.slowDown(0.5)
+	case l.baseline > 0 && latency > time.Duration(float64(l.baseline)*slowFactor):
+		l.slowDown(0.8)
+	default:
+		if l.baseline == 0 {
+			l.baseline = latency
+		} else {
+			l.baseline = (l.baseline*7 + latency) / 8
+		}
+		l.healthy++
+		if l.healthy >= recoverAfter && l.rate < l.maxRate {
+			l.healthy = 0
+			l.setRate(l.rate + l.maxRate/10)
+		}
+	}
+}
+
+// refill adds tokens accrued since the last call. The caller holds l.mu.
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// slowDown scales the rate down by factor. The caller holds l.mu.
+func (l *RateLimiter) slowDown(factor float64) {
+	l.healthy = 0
+	l.setRate(l.rate * factor)
+}
+
+// setRate clamps and applies a new rate. The caller holds l.mu.
+func (l *RateLimiter) setRate(rate float64) {
+	if rate > l.maxRate {
+		rate = l.maxRate
+	}
+	if rate < minAdaptiveRate {
+		rate = minAdaptiveRate
+	}
+	if rate == l.rate {
+		return
+	}
+	l.refill(l.now())
+	log.Debug().Msgf("adjusting request rate from %.2f/s to %.2f/s", l.rate, rate)
+	l.rate = rate
+}
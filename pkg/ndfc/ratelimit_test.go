@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ndfc
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock lets tests drive the limiter without real sleeps.
+type fakeClock struct {
+	t     time.Time
+	slept time.Duration
+}
+
+func newTestLimiter(rps float64, burst int, adaptive bool) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	l := NewRateLimiter(rps, burst, adaptive)
+	l.now = func() time.Time { return clock.t }
+	l.sleep = func(d time.Duration) {
+		clock.slept += d
+		clock.t = clock.t.Add(d)
+	}
+	return l, clock
+}
+
+func TestRateLimiter_BurstThenThrottle(t *testing.T) {
+	l, clock := newTestLimiter(2, 3, false)
+	for i := 0; i < 3; i++ {
+		l.Wait()
+	}
+	assert.Zero(t, clock.slept, "burst requests should not wait")
+
+	l.Wait()
+	assert.Equal(t, 500*time.Millisecond, clock.slept)
+}
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	l, clock := newTestLimiter(100, 10, false)
+	res := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	res.Header.Set("Retry-After", "5")
+	l.Observe(time.Millisecond, res, nil)
+
+	l.Wait()
+	assert.Equal(t, 5*time.Second, clock.slept)
+}
+
+func TestRateLimiter_NonAdaptiveIgnoresErrors(t *testing.T) {
+	l, _ := newTestLimiter(5, 1, false)
+	l.Observe(time.Second, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil)
+	assert.Equal(t, 5.0, l.Rate())
+}
+
+func TestRateLimiter_AdaptiveBacksOffAndRecovers(t *testing.T) {
+	l, _ := newTestLimiter(8, 1, true)
+	ok := &http.Response{StatusCode: http.StatusOK}
+
+	l.Observe(100*time.Millisecond, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil)
+	assert.Equal(t, 4.0, l.Rate())
+	l.Observe(100*time.Millisecond, &http.Response{StatusCode: http.StatusTooManyRequests}, nil)
+	assert.Equal(t, 2.0, l.Rate())
+
+	for i := 0; i < recoverAfter; i++ {
+		l.Observe(100*time.Millisecond, ok, nil)
+	}
+	assert.Equal(t, 2.8, l.Rate())
+
+	for i := 0; i < 20*recoverAfter; i++ {
+		l.Observe(100*time.Millisecond, ok, nil)
+	}
+	assert.Equal(t, 8.0, l.Rate(), "rate should never exceed the configured ceiling")
+}
+
+func TestRateLimiter_AdaptiveSlowResponses(t *testing.T) {
+	l, _ := newTestLimiter(10, 1, true)
+	ok := &http.Response{StatusCode: http.StatusOK}
+	l.Observe(100*time.Millisecond, ok, nil)
+	l.Observe(2*time.Second, ok, nil)
+	assert.Equal(t, 8.0, l.Rate())
+}
+
+func TestRateLimiter_AdaptiveFloor(t *testing.T) {
+	l, _ := newTestLimiter(1, 1, true)
+	for i := 0; i < 20; i++ {
+		l.Observe(time.Second, nil, assert.AnError)
+	}
+	assert.Equal(t, minAdaptiveRate, l.Rate())
+}
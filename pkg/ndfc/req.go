@@ -58,6 +58,8 @@ type Req struct {
 	// Refresh indicates whether token refresh should be checked for this request.
 	// Pass NoRefresh to disable Refresh check.
 	Refresh bool
+	// validators receives the cache validators of the response, if set.
+	validators *Validators
 }
 
 // NoRefresh prevents token refresh check.
@@ -76,3 +78,38 @@ func Query(k, v string) func(req *Req) {
 		req.HTTPReq.URL.RawQuery = q.Encode()
 	}
 }
+
+// Validators are the cache validators NDFC sent with a response. Passed to
+// IfChanged they make a later request for the same resource conditional.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// IsZero reports whether NDFC sent no validators.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// IfChanged makes a request conditional on the resource having changed
+// since the response v was taken from. NDFC answers an unchanged resource
+// with HTTP 304, which Stream returns as a StatusError.
+//
+//	client.GetStream(path, w, ndfc.IfChanged(v))
+func IfChanged(v Validators) func(req *Req) {
+	return func(req *Req) {
+		if v.ETag != "" {
+			req.HTTPReq.Header.Set("If-None-Match", v.ETag)
+		}
+		if v.LastModified != "" {
+			req.HTTPReq.Header.Set("If-Modified-Since", v.LastModified)
+		}
+	}
+}
+
+// SaveValidators stores the cache validators of the response in v.
+func SaveValidators(v *Validators) func(req *Req) {
+	return func(req *Req) {
+		req.validators = v
+	}
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ndfc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VersionMetaKey is the archive manifest meta key of the controller version.
+const VersionMetaKey = "controller_version"
+
+// versionPath is the endpoint NDFC reports its release on.
+const versionPath = "/appcenter/cisco/ndfc/api/about/version"
+
+// Version is an NDFC release such as 12.2.2 or 12.1.3b: dot-separated
+// numbers, optionally followed by a letter suffix for patch builds. The
+// zero Version stands for an unknown release.
+type Version struct {
+	parts  []int
+	suffix string
+}
+
+// ParseVersion parses an NDFC release. Version bounds may be given with
+// fewer parts, e.g. 12 or 12.2.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return Version{}, fmt.Errorf("empty version")
+	}
+	var v Version
+	fields := strings.Split(s, ".")
+	for i, field := range fields {
+		if i == len(fields)-1 {
+			end := strings.IndexFunc(field, func(r rune) bool { return r < '0' || r > '9' })
+			if end > 0 {
+				field, v.suffix = field[:end], field[end:]
+			}
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		v.parts = append(v.parts, n)
+	}
+	return v, nil
+}
+
+// IsZero reports whether the version is unknown.
+func (v Version) IsZero() bool {
+	return len(v.parts) == 0
+}
+
+func (v Version) String() string {
+	parts := make([]string, len(v.parts))
+	for i, n := range v.parts {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".") + v.suffix
+}
+
+// compare compares v to the bound b at the precision of b, so that v is
+// equal to 12.2 for every 12.2.x, and to 12.1.3 for 12.1.3 and 12.1.3b. It
+// returns -1, 0 or +1.
+func (v Version) compare(b Version) int {
+	for i, n := range b.parts {
+		var m int
+		if i < len(v.parts) {
+			m = v.parts[i]
+		}
+		if m != n {
+			if m < n {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(v.parts) > len(b.parts) || b.suffix == "" {
+		return 0
+	}
+	return strings.Compare(v.suffix, b.suffix)
+}
+
+// Between reports whether v lies within the inclusive bounds lo and hi,
+// either of which may be zero for no bound. An unknown version is within
+// any bounds.
+func (v Version) Between(lo, hi Version) bool {
+	if v.IsZero() {
+		return true
+	}
+	return (lo.IsZero() || v.compare(lo) >= 0) && (hi.IsZero() || v.compare(hi) <= 0)
+}
+
+// GetVersion asks NDFC for its release. It must be called after Login.
+func (client *Client) GetVersion() (Version, error) {
+	res, err := client.Get(versionPath)
+	if err != nil {
+		return Version{}, err
+	}
+	return ParseVersion(res.Get("version").Str)
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ndfc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	for in, want := range map[string]string{
+		"12.2.2":   "12.2.2",
+		"12.1.3b":  "12.1.3b",
+		" v12.2 ":  "12.2",
+		"12":       "12",
+		"12.1.2e ": "12.1.2e",
+	} {
+		v, err := ParseVersion(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, v.String(), in)
+	}
+	for _, in := range []string{"", "twelve", "12..2", "12.x.1", "12.-1"} {
+		_, err := ParseVersion(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestVersionBetween(t *testing.T) {
+	v := func(s string) Version {
+		if s == "" {
+			return Version{}
+		}
+		parsed, err := ParseVersion(s)
+		require.NoError(t, err)
+		return parsed
+	}
+	for _, tc := range []struct {
+		version, lo, hi string
+		want            bool
+	}{
+		{"12.2.2", "12.2", "", true},
+		{"12.1.3b", "12.2", "", false},
+		{"12.1.3b", "", "12.1", true},
+		{"12.1.3b", "", "12.1.3", true},
+		{"12.1.3b", "", "12.1.3a", false},
+		{"12.1.3a", "12.1.3a", "12.1.3a", true},
+		{"12.2", "12.2.1", "", false},
+		{"12.2.1", "12.1", "12.1", false},
+		{"12.2.1", "", "", true},
+		{"", "13", "", true},
+	} {
+		assert.Equal(t, tc.want, v(tc.version).Between(v(tc.lo), v(tc.hi)),
+			"%s between %q and %q", tc.version, tc.lo, tc.hi)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, versionPath, r.URL.Path)
+		w.Write([]byte(`{"version":"12.2.2","mode":"LAN"}`))
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.URL, "admin", "secret")
+	require.NoError(t, err)
+	v, err := client.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "12.2.2", v.String())
+}
@@ -0,0 +1,264 @@
+// Package progress draws a live terminal display of a collection run.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ndfc-collector/pkg/metrics"
+)
+
+// refresh is the interval between redraws.
+const refresh = 250 * time.Millisecond
+
+// barWidth is the width of a level's progress bar.
+const barWidth = 30
+
+type level struct {
+	level int
+	total int
+	done  int
+	start time.Time
+	end   time.Time
+}
+
+// Tracker draws per-level progress bars, the requests in flight, the
+// throughput, retries and failures, and an estimate for the current level.
+// Totals are read from the run's metrics. A nil Tracker ignores every call,
+// for runs without the display.
+type Tracker struct {
+	mu       sync.Mutex
+	w        io.Writer
+	width    int
+	metrics  *metrics.Metrics
+	now      func() time.Time
+	start    time.Time
+	levels   []*level
+	inflight map[string]time.Time
+	lines    int // lines of the last frame
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New starts drawing to w, a terminal width columns wide, until Stop.
+func New(w io.Writer, width int, m *metrics.Metrics) *Tracker {
+	t := newTracker(w, width, m, time.Now)
+	go t.run()
+	return t
+}
+
+func newTracker(w io.Writer, width int, m *metrics.Metrics, now func() time.Time) *Tracker {
+	if width <= 0 {
+		width = 80
+	}
+	return &Tracker{
+		w:        w,
+		width:    width,
+		metrics:  m,
+		now:      now,
+		start:    now(),
+		inflight: map[string]time.Time{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (t *Tracker) run() {
+	defer close(t.done)
+	tick := time.NewTicker(refresh)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			t.draw()
+		case <-t.stop:
+			t.draw()
+			return
+		}
+	}
+}
+
+// Stop draws the final frame and stops the display.
+func (t *Tracker) Stop() {
+	if t == nil {
+		return
+	}
+	close(t.stop)
+	<-t.done
+}
+
+// StartLevel records that dependency level n, with total requests, began.
+func (t *Tracker) StartLevel(n, total int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if len(t.levels) > 0 {
+		if prev := t.levels[len(t.levels)-1]; prev.end.IsZero() {
+			prev.end = now
+		}
+	}
+	t.levels = append(t.levels, &level{level: n, total: total, start: now})
+}
+
+// Begin records that the request for name is in flight.
+func (t *Tracker) Begin(name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inflight[name] = t.now()
+}
+
+// Done records that the request for name finished, successfully or not.
+func (t *Tracker) Done(name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.inflight, name)
+	if len(t.levels) > 0 {
+		l := t.levels[len(t.levels)-1]
+		l.done++
+		if l.done == l.total {
+			l.end = t.now()
+		}
+	}
+}
+
+// draw replaces the previous frame with the current one.
+func (t *Tracker) draw() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var b strings.Builder
+	if t.lines > 0 {
+		// Move to the start of the previous frame and clear it.
+		fmt.Fprintf(&b, "\033[%dF\033[J", t.lines)
+	}
+	frame := t.frame()
+	for _, line := range frame {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	t.lines = len(frame)
+	io.WriteString(t.w, b.String())
+}
+
+// frame renders the display as lines no wider than the terminal.
+func (t *Tracker) frame() []string {
+	now := t.now()
+	var lines []string
+	for _, l := range t.levels {
+		filled := barWidth
+		if l.total > 0 {
+			filled = barWidth * l.done / l.total
+		}
+		line := fmt.Sprintf("Level %d [%s%s] %d/%d", l.level,
+			strings.Repeat("#", filled), strings.Repeat(".", barWidth-filled), l.done, l.total)
+		switch {
+		case !l.end.IsZero():
+			line += "  done in " + formatDuration(l.end.Sub(l.start))
+		case l.done > 0:
+			perRequest := now.Sub(l.start) / time.Duration(l.done)
+			line += "  ETA " + formatDuration(perRequest*time.Duration(l.total-l.done))
+		}
+		lines = append(lines, line)
+	}
+
+	if len(t.inflight) > 0 {
+		names := make([]string, 0, len(t.inflight))
+		for name := range t.inflight {
+			names = append(names, name)
+		}
+		// Longest running first.
+		sort.Slice(names, func(i, j int) bool {
+			ti, tj := t.inflight[names[i]], t.inflight[names[j]]
+			if !ti.Equal(tj) {
+				return ti.Before(tj)
+			}
+			return names[i] < names[j]
+		})
+		lines = append(lines, inflightLine(names, t.width))
+	}
+
+	s := t.metrics.Summary()
+	elapsed := now.Sub(t.start)
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(s.Bytes) / elapsed.Seconds()
+	}
+	lines = append(lines, fmt.Sprintf("%d requests, %s at %s/s, %d retries, %d failed, %s elapsed",
+		s.Requests, formatBytes(float64(s.Bytes)), formatBytes(rate), s.Retries, s.Failures, formatDuration(elapsed)))
+
+	for i, line := range lines {
+		lines[i] = truncate(line, t.width-1)
+	}
+	return lines
+}
+
+// inflightLine lists as many names as fit in width.
+func inflightLine(names []string, width int) string {
+	line := "Fetching " + names[0]
+	for i, name := range names[1:] {
+		more := fmt.Sprintf(" (+%d more)", len(names)-1-i)
+		if len(line)+len(", "+name)+len(more) >= width {
+			return line + more
+		}
+		line += ", " + name
+	}
+	return line
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n || n < 4 {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// formatBytes renders a byte count with a decimal unit.
+func formatBytes(n float64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	exp := 0
+	for n >= unit*unit && exp < 5 {
+		n /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", n/unit, "kMGTPE"[exp])
+}
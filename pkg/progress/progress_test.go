@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ndfc-collector/pkg/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is advanced manually by tests.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func TestTracker_Frame(t *testing.T) {
+	clock := &fakeClock{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := metrics.New()
+	var out strings.Builder
+	tr := newTracker(&out, 80, m, clock.now)
+
+	tr.StartLevel(0, 1)
+	tr.Begin("manage.fabrics.json")
+	clock.t = clock.t.Add(2 * time.Second)
+	m.Request("manage/fabrics", 2*time.Second, 4000, nil)
+	tr.Done("manage.fabrics.json")
+
+	tr.StartLevel(1, 4)
+	for _, name := range []string{"fabrics.f1.vrfs.json", "fabrics.f2.vrfs.json", "fabrics.f3.vrfs.json"} {
+		tr.Begin(name)
+		clock.t = clock.t.Add(time.Second)
+	}
+	m.Retry("fabrics/{fabricName}/vrfs")
+	m.Request("fabrics/{fabricName}/vrfs", time.Second, 1000, nil)
+	tr.Done("fabrics.f1.vrfs.json")
+
+	lines := tr.frame()
+	require.Len(t, lines, 4)
+	assert.Equal(t, "Level 0 [##############################] 1/1  done in 2s", lines[0])
+	assert.Equal(t, "Level 1 [#######.......................] 1/4  ETA 9s", lines[1])
+	assert.Equal(t, "Fetching fabrics.f2.vrfs.json, fabrics.f3.vrfs.json", lines[2])
+	assert.Equal(t, "2 requests, 5.0 kB at 1.0 kB/s, 1 retries, 0 failed, 5s elapsed", lines[3])
+
+	tr.draw()
+	tr.draw()
+	assert.Equal(t, 2, strings.Count(out.String(), "Level 0"), "every draw writes a frame")
+	assert.Contains(t, out.String(), "\033[4F\033[J", "redraws replace the previous frame")
+}
+
+func TestInflightLine(t *testing.T) {
+	names := []string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "dddddddddd"}
+	assert.Equal(t, "Fetching aaaaaaaaaa, bbbbbbbbbb, cccccccccc, dddddddddd", inflightLine(names, 80))
+	assert.Equal(t, "Fetching aaaaaaaaaa, bbbbbbbbbb (+2 more)", inflightLine(names, 45))
+}
+
+func TestTracker_Nil(t *testing.T) {
+	var tr *Tracker
+	assert.NotPanics(t, func() {
+		tr.StartLevel(0, 1)
+		tr.Begin("x")
+		tr.Done("x")
+		tr.Stop()
+	})
+}
+
+func TestTracker_Stop(t *testing.T) {
+	var out strings.Builder
+	tr := New(&out, 80, metrics.New())
+	tr.StartLevel(0, 1)
+	tr.Stop()
+	assert.Contains(t, out.String(), "Level 0 [")
+}
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Redact copies the JSON document in to out with every matching value
+// redacted. The document is processed token by token, so memory use does
+// not depend on its size. Output is compact JSON unless SetIndent was used.
+func (r *Redactor) Redact(in io.Reader, out io.Writer) error {
+	s := &stream{
+		r:      r,
+		dec:    json.NewDecoder(in),
+		w:      bufio.NewWriter(out),
+		indent: r.indent,
+	}
+	s.dec.UseNumber()
+	s.enc = json.NewEncoder(&s.buf)
+	s.enc.SetEscapeHTML(false)
+	for {
+		tok, err := s.dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("decoding JSON: %w", err)
+		}
+		if err := s.value(tok, nil, nil); err != nil {
+			return err
+		}
+		if s.indent != "" {
+			s.w.WriteByte('\n')
+		}
+	}
+	return s.w.Flush()
+}
+
+type stream struct {
+	r   *Redactor
+	dec *json.Decoder
+	w   *bufio.Writer
+	buf bytes.Buffer
+	enc *json.Encoder // encodes strings into buf without HTML escaping
+
+	indent string // per-level indent; empty for compact output
+	depth  int
+}
+
+// newline starts a new line at the current depth when indenting.
+func (s *stream) newline() {
+	if s.indent == "" {
+		return
+	}
+	s.w.WriteByte('\n')
+	for range s.depth {
+		s.w.WriteString(s.indent)
+	}
+}
+
+// value writes the value starting with tok found at path. A non-nil rule
+// means an ancestor matched, so every scalar inside is redacted with it.
+func (s *stream) value(tok json.Token, path []string, rule *Rule) error {
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return s.object(path, rule)
+		}
+		return s.array(path, rule)
+	case string:
+		if rule != nil {
+			return s.str(s.r.value(rule, t))
+		}
+		return s.str(s.r.String(t))
+	case json.Number:
+		if rule != nil {
+			return s.str(s.r.value(rule, t.String()))
+		}
+		_, err := s.w.WriteString(t.String())
+		return err
+	case bool:
+		_, err := s.w.WriteString(strconv.FormatBool(t))
+		return err
+	case nil:
+		_, err := s.w.WriteString("null")
+		return err
+	}
+	return fmt.Errorf("unexpected JSON token %v", tok)
+}
+
+func (s *stream) object(path []string, rule *Rule) error {
+	s.w.WriteByte('{')
+	s.depth++
+	first := true
+	for s.dec.More() {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return fmt.Errorf("decoding JSON: %w", err)
+		}
+		key, _ := tok.(string)
+		childPath := append(path[:len(path):len(path)], key)
+
+		childRule := rule
+		if childRule == nil {
+			childRule = s.r.match(childPath)
+		}
+
+		tok, err = s.dec.Token()
+		if err != nil {
+			return fmt.Errorf("decoding JSON: %w", err)
+		}
+		if childRule != nil && childRule.Action == Remove {
+			if err := s.skip(tok); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !first {
+			s.w.WriteByte(',')
+		}
+		first = false
+		s.newline()
+		if err := s.str(key); err != nil {
+			return err
+		}
+		s.w.WriteByte(':')
+		if s.indent != "" {
+			s.w.WriteByte(' ')
+		}
+		if err := s.value(tok, childPath, childRule); err != nil {
+			return err
+		}
+	}
+	if _, err := s.dec.Token(); err != nil {
+		return fmt.Errorf("decoding JSON: %w", err)
+	}
+	s.depth--
+	if !first {
+		s.newline()
+	}
+	return s.w.WriteByte('}')
+}
+
+func (s *stream) array(path []string, rule *Rule) error {
+	s.w.WriteByte('[')
+	s.depth++
+	first := true
+	for i := 0; s.dec.More(); i++ {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return fmt.Errorf("decoding JSON: %w", err)
+		}
+		childPath := append(path[:len(path):len(path)], strconv.Itoa(i))
+		childRule := rule
+		if childRule == nil {
+			for _, pr := range s.r.paths {
+				if pathMatches(pr.path, childPath) {
+					childRule = pr
+					break
+				}
+			}
+		}
+		if childRule != nil && childRule.Action == Remove {
+			if err := s.skip(tok); err != nil {
+				return err
+			}
+			continue
+		}
+		if !first {
+			s.w.WriteByte(',')
+		}
+		first = false
+		s.newline()
+		if err := s.value(tok, childPath, childRule); err != nil {
+			return err
+		}
+	}
+	if _, err := s.dec.Token(); err != nil {
+		return fmt.Errorf("decoding JSON: %w", err)
+	}
+	s.depth--
+	if !first {
+		s.newline()
+	}
+	return s.w.WriteByte(']')
+}
+
+// skip discards the remainder of a value whose first token was tok.
+func (s *stream) skip(tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return fmt.Errorf("decoding JSON: %w", err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// str writes v as a JSON string.
+func (s *stream) str(v string) error {
+	s.buf.Reset()
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := s.w.Write(bytes.TrimSuffix(s.buf.Bytes(), []byte("\n")))
+	return err
+}
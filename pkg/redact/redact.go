@@ -0,0 +1,271 @@
+// Package redact scrubs sensitive values from collected data before it is
+// written to an archive.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Actions applied to matched values.
+const (
+	Pseudonymize = "pseudonymize"
+	Mask         = "mask"
+	Remove       = "remove"
+)
+
+// maskValue replaces masked values.
+const maskValue = "********"
+
+// Rule selects values to redact. Exactly one of Field, Path or Pattern is set.
+type Rule struct {
+	Field    string `yaml:"field"`    // JSON object key matched at any depth (case-insensitive)
+	Path     string `yaml:"path"`     // dot-notation path from the root; "#" matches any index, "*" any key
+	Pattern  string `yaml:"pattern"`  // regex applied to string values and entry names
+	Category string `yaml:"category"` // token prefix for pseudonymized values
+	Action   string `yaml:"action"`   // pseudonymize (default), mask or remove
+
+	re   *regexp.Regexp
+	path []string
+}
+
+//go:embed rules.yaml
+var defaultRulesYAML []byte
+
+// DefaultRules returns the built-in rules covering serial numbers, IP
+// addresses, hostnames, usernames and passwords.
+func DefaultRules() ([]Rule, error) {
+	return parseRules(defaultRulesYAML)
+}
+
+// LoadRules reads rules from a YAML file in the same format as the
+// built-in rules.yaml.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading redaction rules: %w", err)
+	}
+	return parseRules(data)
+}
+
+func parseRules(data []byte) ([]Rule, error) {
+	var raw struct {
+		Rules []Rule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing redaction rules: %w", err)
+	}
+	return raw.Rules, nil
+}
+
+// Redactor applies redaction rules, pseudonymizing values consistently:
+// the same input always maps to the same token for a given key, across
+// every file in a collection and across collections.
+type Redactor struct {
+	fields   map[string]*Rule
+	paths    []*Rule
+	patterns []*Rule
+	key      []byte
+	indent   string
+
+	mu      sync.Mutex
+	mapping map[string]string // token -> original value
+}
+
+// New validates rules and returns a Redactor that derives pseudonyms from key.
+func New(rules []Rule, key []byte) (*Redactor, error) {
+	r := &Redactor{
+		fields:  map[string]*Rule{},
+		key:     key,
+		mapping: map[string]string{},
+	}
+	for i := range rules {
+		rule := rules[i]
+		if rule.Action == "" {
+			rule.Action = Pseudonymize
+		}
+		if rule.Action != Pseudonymize && rule.Action != Mask && rule.Action != Remove {
+			return nil, fmt.Errorf("rule %d: unknown action %q", i, rule.Action)
+		}
+		if rule.Category == "" {
+			rule.Category = "redacted"
+		}
+		switch {
+		case rule.Field != "" && rule.Path == "" && rule.Pattern == "":
+			r.fields[strings.ToLower(rule.Field)] = &rule
+		case rule.Path != "" && rule.Field == "" && rule.Pattern == "":
+			rule.path = strings.Split(rule.Path, ".")
+			r.paths = append(r.paths, &rule)
+		case rule.Pattern != "" && rule.Field == "" && rule.Path == "":
+			if rule.Action == Remove {
+				return nil, fmt.Errorf("rule %d: patterns cannot use the remove action", i)
+			}
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i, err)
+			}
+			rule.re = re
+			r.patterns = append(r.patterns, &rule)
+		default:
+			return nil, fmt.Errorf("rule %d: exactly one of field, path or pattern is required", i)
+		}
+	}
+	return r, nil
+}
+
+// SetIndent makes Redact indent its output by indent per level, in the
+// same layout as json.Encoder, so normalized responses stay diffable after
+// redaction.
+func (r *Redactor) SetIndent(indent string) {
+	r.indent = indent
+}
+
+// match returns the field or path rule matching the value at path, if any.
+func (r *Redactor) match(path []string) *Rule {
+	if rule, ok := r.fields[strings.ToLower(path[len(path)-1])]; ok {
+		return rule
+	}
+	for _, rule := range r.paths {
+		if pathMatches(rule.path, path) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// pathMatches compares a rule path to a document path. Array indices in
+// the document path are decimal strings.
+func pathMatches(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, seg := range pattern {
+		switch {
+		case seg == "*", seg == path[i]:
+		case seg == "#" && isIndex(path[i]):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// value returns the replacement for a scalar matched by rule.
+func (r *Redactor) value(rule *Rule, val string) string {
+	if rule.Action == Mask {
+		return maskValue
+	}
+	return r.token(rule.Category, val)
+}
+
+// token returns the deterministic pseudonym for val and records it in the
+// mapping.
+func (r *Redactor) token(category, val string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(category))
+	mac.Write([]byte{0})
+	mac.Write([]byte(val))
+	token := category + "-" + hex.EncodeToString(mac.Sum(nil))[:10]
+
+	r.mu.Lock()
+	r.mapping[token] = val
+	r.mu.Unlock()
+	return token
+}
+
+// String applies pattern rules to s.
+func (r *Redactor) String(s string) string {
+	for _, rule := range r.patterns {
+		s = r.replace(rule, s)
+	}
+	return s
+}
+
+// replace substitutes every match of rule in s. When the pattern has a
+// capture group only the first group is replaced.
+func (r *Redactor) replace(rule *Rule, s string) string {
+	matches := rule.re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(r.value(rule, s[start:end]))
+		last = end
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+// Name redacts an archive entry name, e.g. one derived from a db_key
+// containing a switch serial number.
+func (r *Redactor) Name(name string) string {
+	return r.String(name)
+}
+
+// Mapping returns the token to original value mapping as indented JSON,
+// sorted by token.
+func (r *Redactor) Mapping() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokens := make([]string, 0, len(r.mapping))
+	for token := range r.mapping {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	ordered := make([]struct {
+		Token string `json:"token"`
+		Value string `json:"value"`
+	}, len(tokens))
+	for i, token := range tokens {
+		ordered[i].Token = token
+		ordered[i].Value = r.mapping[token]
+	}
+	return json.MarshalIndent(ordered, "", "  ")
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/jsonstream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func redactString(t *testing.T, r *Redactor, doc string) string {
+	t.Helper()
+	var sb strings.Builder
+	require.NoError(t, r.Redact(strings.NewReader(doc), &sb))
+	return sb.String()
+}
+
+func TestDefaultRules(t *testing.T) {
+	rules, err := DefaultRules()
+	require.NoError(t, err)
+	r, err := New(rules, []byte("key"))
+	require.NoError(t, err)
+
+	doc := `{"switches":[{"serialNumber":"FDO123","switchName":"leaf-1","ipAddress":"10.1.1.1/24",
+		"freeformConfig":"username admin password 5 $1$abc$def\ninterface e1/1","model":"N9K-C93180YC-EX",
+		"vpc":{"peerSerialNumber":"FDO456"},"password":"hunter2"}]}`
+	out := gjson.Parse(redactString(t, r, doc)).Get("switches.0")
+
+	assert.Regexp(t, `^serial-[0-9a-f]{10}$`, out.Get("serialNumber").String())
+	assert.Regexp(t, `^host-[0-9a-f]{10}$`, out.Get("switchName").String())
+	assert.Regexp(t, `^ip-[0-9a-f]{10}/24$`, out.Get("ipAddress").String())
+	assert.Equal(t, "N9K-C93180YC-EX", out.Get("model").String(), "unmatched values are kept")
+	assert.Equal(t, maskValue, out.Get("password").String())
+
+	config := out.Get("freeformConfig").String()
+	assert.NotContains(t, config, "admin")
+	assert.NotContains(t, config, "$1$abc$def")
+	assert.Contains(t, config, "password 5 "+maskValue)
+	assert.Contains(t, config, "interface e1/1")
+}
+
+func TestDefaultRules_Addresses(t *testing.T) {
+	rules, err := DefaultRules()
+	require.NoError(t, err)
+	r, err := New(rules, []byte("key"))
+	require.NoError(t, err)
+
+	doc := `{"a":"2001:db8::1/64","b":"fe80::a:2","c":"2001:db8:0:0:1:0:0:1","d":"2001:db8::",
+		"e":"::1","mac":"00:11:22:33:44:55","time":"12:30:45","UserName":"admin"}`
+	out := gjson.Parse(redactString(t, r, doc))
+
+	assert.Regexp(t, `^ip-[0-9a-f]{10}/64$`, out.Get("a").String())
+	for _, key := range []string{"b", "c", "d", "e"} {
+		assert.Regexp(t, `^ip-[0-9a-f]{10}$`, out.Get(key).String(), key)
+	}
+	assert.Equal(t, "00:11:22:33:44:55", out.Get("mac").String())
+	assert.Equal(t, "12:30:45", out.Get("time").String())
+	assert.Regexp(t, `^user-[0-9a-f]{10}$`, out.Get("UserName").String(), "fields match case-insensitively")
+}
+
+func TestPseudonymsAreConsistent(t *testing.T) {
+	rules := []Rule{{Field: "serialNumber", Category: "serial"}}
+	r1, err := New(rules, []byte("key"))
+	require.NoError(t, err)
+	r2, err := New(rules, []byte("key"))
+	require.NoError(t, err)
+	r3, err := New(rules, []byte("other"))
+	require.NoError(t, err)
+
+	a := gjson.Get(redactString(t, r1, `{"serialNumber":"SN1"}`), "serialNumber").String()
+	b := gjson.Get(redactString(t, r1, `[{"serialNumber":"SN1"}]`), "0.serialNumber").String()
+	c := gjson.Get(redactString(t, r2, `{"serialNumber":"SN1"}`), "serialNumber").String()
+	d := gjson.Get(redactString(t, r3, `{"serialNumber":"SN1"}`), "serialNumber").String()
+	assert.Equal(t, a, b, "same value in different files")
+	assert.Equal(t, a, c, "same value in different runs with the same key")
+	assert.NotEqual(t, a, d, "different keys give different tokens")
+}
+
+func TestPathAndRemoveRules(t *testing.T) {
+	r, err := New([]Rule{
+		{Path: "fabrics.#.contact", Category: "contact"},
+		{Path: "fabrics.#.nvPairs.*", Action: Mask},
+		{Field: "token", Action: Remove},
+	}, []byte("key"))
+	require.NoError(t, err)
+
+	out := redactString(t, r, `{"contact":"top","token":"x","fabrics":[{"contact":"bob","nvPairs":{"a":1,"b":"x"},"token":{"deep":1}}]}`)
+	assert.Equal(t, "top", gjson.Get(out, "contact").String(), "paths are anchored at the root")
+	assert.False(t, gjson.Get(out, "token").Exists())
+	assert.False(t, gjson.Get(out, "fabrics.0.token").Exists())
+	assert.Regexp(t, `^contact-`, gjson.Get(out, "fabrics.0.contact").String())
+	assert.Equal(t, `{"a":"********","b":"********"}`, gjson.Get(out, "fabrics.0.nvPairs").Raw)
+	assert.True(t, json.Valid([]byte(out)))
+}
+
+func TestRedactPreservesStructure(t *testing.T) {
+	r, err := New(nil, []byte("key"))
+	require.NoError(t, err)
+	doc := `{"a":[1,2.50,true,null,{"b":"<x>"}],"c":{}}`
+	assert.Equal(t, doc, redactString(t, r, doc))
+}
+
+func TestRedactIndent(t *testing.T) {
+	r, err := New([]Rule{{Field: "secret", Action: Remove}}, []byte("key"))
+	require.NoError(t, err)
+	r.SetIndent("  ")
+
+	var normalized strings.Builder
+	doc := `{"b":[1,{"c":"<x>"}],"a":{},"e":[],"secret":1}`
+	require.NoError(t, jsonstream.Normalize(strings.NewReader(doc), &normalized, "", ""))
+	want := strings.Replace(normalized.String(), ",\n  \"secret\": 1", "", 1)
+	assert.Equal(t, want, redactString(t, r, normalized.String()), "layout matches normalized output")
+}
+
+func TestNew_InvalidRules(t *testing.T) {
+	_, err := New([]Rule{{Field: "a", Path: "b"}}, nil)
+	assert.Error(t, err)
+	_, err = New([]Rule{{Pattern: "("}}, nil)
+	assert.Error(t, err)
+	_, err = New([]Rule{{Field: "a", Action: "shred"}}, nil)
+	assert.Error(t, err)
+	_, err = New([]Rule{{Pattern: "a", Action: Remove}}, nil)
+	assert.Error(t, err)
+}
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+	next, err := archive.NewDirWriter(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+	r, err := New([]Rule{
+		{Field: "serialNumber", Category: "serial"},
+		{Pattern: `FDO\d+`, Category: "serial"},
+	}, []byte("key"))
+	require.NoError(t, err)
+
+	w := NewWriter(next, r)
+	mappingPath := filepath.Join(dir, "mapping.json")
+	w.WriteMapping(mappingPath, func(w io.Writer) (io.WriteCloser, error) {
+		return nopCloser{w}, nil
+	})
+	require.NoError(t, w.Add("switches.FDO123.json", []byte(`{"serialNumber":"FDO123"}`)))
+	w.SetMeta("requests", []map[string]string{{"entry": "switches.FDO123.json"}})
+	assert.Error(t, w.Add("broken.json", []byte(`{"serialNumber":`)))
+	require.NoError(t, w.Close())
+
+	token := r.token("serial", "FDO123")
+	data, err := os.ReadFile(filepath.Join(dir, "out", "switches."+token+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"serialNumber":"`+token+`"}`, string(data))
+
+	manifest, err := os.ReadFile(filepath.Join(dir, "out", archive.ManifestName))
+	require.NoError(t, err)
+	assert.True(t, gjson.GetBytes(manifest, "meta.redacted").Bool())
+	assert.Equal(t, "switches."+token+".json", gjson.GetBytes(manifest, "meta.requests.0.entry").String(),
+		"metadata is redacted consistently with entry names")
+
+	mapping, err := os.ReadFile(mappingPath)
+	require.NoError(t, err)
+	assert.Equal(t, "FDO123", gjson.GetBytes(mapping, `#(token=="`+token+`").value`).String())
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
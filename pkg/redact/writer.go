@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"ndfc-collector/pkg/archive"
+)
+
+// MetaKey is the manifest metadata key marking a redacted archive.
+const MetaKey = "redacted"
+
+// Writer is an archive.Writer that redacts every entry before passing it on
+// to the next writer.
+type Writer struct {
+	next    archive.Writer
+	r       *Redactor
+	mapping func() error
+}
+
+// NewWriter wraps next so that entries are redacted by r. The manifest
+// records that the archive is redacted.
+func NewWriter(next archive.Writer, r *Redactor) *Writer {
+	next.SetMeta(MetaKey, true)
+	return &Writer{next: next, r: r}
+}
+
+// WriteMapping makes Close save the token to original value mapping to
+// path, encrypted with encrypt (e.g. crypt.NewWriter). The mapping stays
+// with the collecting side and allows reports on the redacted archive to
+// be translated back.
+func (w *Writer) WriteMapping(path string, encrypt func(io.Writer) (io.WriteCloser, error)) {
+	w.mapping = func() error {
+		data, err := w.r.Mapping()
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		enc, err := encrypt(f)
+		if err == nil {
+			_, err = enc.Write(data)
+			if cerr := enc.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			return fmt.Errorf("writing redaction mapping: %w", err)
+		}
+		return nil
+	}
+}
+
+// Add redacts content and adds it to the next writer.
+func (w *Writer) Add(name string, content []byte) error {
+	return w.AddReader(name, bytes.NewReader(content))
+}
+
+// AddReader redacts the JSON read from rd as it is streamed into the next
+// writer.
+func (w *Writer) AddReader(name string, rd io.Reader) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(w.r.Redact(rd, pw))
+	}()
+	err := w.next.AddReader(w.r.Name(name), pr)
+	// Unblock the redacting goroutine if the next writer stopped early.
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return fmt.Errorf("redacting %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetMeta records a collection-level value in the next writer's manifest,
+// redacted like an entry. Entry names recorded in the value, such as those
+// of request results, match the redacted names in the archive.
+func (w *Writer) SetMeta(key string, value any) {
+	w.next.SetMeta(key, w.redactValue(value))
+}
+
+// redactValue passes value through the redactor as JSON. Values that
+// cannot be redacted are replaced with a placeholder rather than leaked.
+func (w *Writer) redactValue(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return maskValue
+	}
+	var buf bytes.Buffer
+	if err := w.r.Redact(bytes.NewReader(data), &buf); err != nil {
+		return maskValue
+	}
+	var out any
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		return maskValue
+	}
+	return out
+}
+
+// Close writes the mapping file, if requested, and closes the next writer.
+func (w *Writer) Close() error {
+	var mapErr error
+	if w.mapping != nil {
+		mapErr = w.mapping()
+	}
+	if err := w.next.Close(); err != nil {
+		return err
+	}
+	return mapErr
+}
@@ -0,0 +1,266 @@
+// Package report renders a self-contained HTML summary of a collection archive.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/export"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/tidwall/gjson"
+)
+
+// db_key templates of the requests summarized by the report.
+const (
+	keyFabrics   = "manage/fabrics"
+	keySwitches  = "inventory/switches"
+	keyVRFs      = "fabrics/{fabricName}/vrfs"
+	keyNetworks  = "fabrics/{fabricName}/networks"
+	keyAnomalies = "analyze/anomalies/summary"
+	keyCluster   = "infra/cluster/config"
+	keyBackups   = "infra/backups"
+	keyLicenses  = "infra/license/assignments"
+)
+
+// Field is a named value shown in a key/value table.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Count is a value and the number of items having it.
+type Count struct {
+	Value string
+	Count int
+}
+
+// Fabric summarizes one fabric.
+type Fabric struct {
+	Name     string
+	Type     string
+	Switches int
+	VRFs     int
+	Networks int
+}
+
+// Backup describes a controller backup.
+type Backup struct {
+	Name    string
+	Created string
+	Status  string
+}
+
+// Summary is the data rendered into the report.
+type Summary struct {
+	Created    time.Time
+	Meta       []Field // scalar manifest metadata, e.g. the collector version
+	Controller []Field
+	Fabrics    []Fabric
+	Switches   int
+	Models     []Count
+	Versions   []Count
+	Anomalies  []Field
+	Backups    int
+	Latest     *Backup // most recent backup
+	Licenses   []Count
+	Requests   int
+	Failed     []requests.Result
+}
+
+// Build summarizes the archive r. catalog supplies the list_path and
+// id_field of each request, as for export.Walk.
+func Build(r archive.Reader, catalog []requests.Request) (*Summary, error) {
+	data, err := archive.ReadFile(r, archive.ManifestName)
+	if err != nil {
+		return nil, err
+	}
+	var m archive.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	results, err := export.Results(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Summary{Created: m.Created, Requests: len(results)}
+	for _, k := range sortedKeys(m.Meta) {
+		switch v := m.Meta[k].(type) {
+		case string, bool, float64:
+			s.Meta = append(s.Meta, Field{k, fmt.Sprint(v)})
+		}
+	}
+	for _, res := range results {
+		if res.Error != "" {
+			s.Failed = append(s.Failed, res)
+		}
+	}
+
+	fabrics := map[string]*Fabric{}
+	fabric := func(name string) *Fabric {
+		f, ok := fabrics[name]
+		if !ok {
+			f = &Fabric{Name: name}
+			fabrics[name] = f
+		}
+		return f
+	}
+	models, versions, licenses := map[string]int{}, map[string]int{}, map[string]int{}
+	var latestTime gjson.Result
+
+	err = export.Walk(r, catalog, func(rec export.Record) error {
+		item := gjson.ParseBytes(rec.Item)
+		switch rec.Template {
+		case keyFabrics:
+			f := fabric(first(item, "name", "fabricName"))
+			f.Type = first(item, "fabricType", "type", "templateName")
+		case keySwitches:
+			s.Switches++
+			fabric(first(item, "fabricName", "fabric")).Switches++
+			models[first(item, "model")]++
+			versions[first(item, "softwareVersion", "release", "version")]++
+		case keyVRFs:
+			fabric(rec.Ctx["fabricName"]).VRFs++
+		case keyNetworks:
+			fabric(rec.Ctx["fabricName"]).Networks++
+		case keyCluster:
+			s.Controller = append(s.Controller, scalars("", item)...)
+		case keyAnomalies:
+			s.Anomalies = append(s.Anomalies, scalars("", item)...)
+		case keyBackups:
+			s.Backups++
+			created := firstResult(item, "createdTime", "createdAt", "timestamp")
+			if s.Latest == nil || newer(created, latestTime) {
+				latestTime = created
+				s.Latest = &Backup{
+					Name:    first(item, "name"),
+					Created: created.String(),
+					Status:  first(item, "status", "state"),
+				}
+			}
+		case keyLicenses:
+			licenses[first(item, "licenseState", "state", "status")]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(fabrics) {
+		s.Fabrics = append(s.Fabrics, *fabrics[name])
+	}
+	s.Models = counts(models)
+	s.Versions = counts(versions)
+	s.Licenses = counts(licenses)
+	return s, nil
+}
+
+// firstResult returns the first of paths present in item.
+func firstResult(item gjson.Result, paths ...string) gjson.Result {
+	for _, p := range paths {
+		if v := item.Get(p); v.Exists() {
+			return v
+		}
+	}
+	return gjson.Result{}
+}
+
+// first returns the first of paths present in item as a string, or
+// "unknown".
+func first(item gjson.Result, paths ...string) string {
+	if v := firstResult(item, paths...); v.Exists() && v.String() != "" {
+		return v.String()
+	}
+	return "unknown"
+}
+
+// newer reports whether timestamp a is later than b. Numeric timestamps
+// are compared as numbers and others as text, which orders ISO 8601 times.
+func newer(a, b gjson.Result) bool {
+	if a.Type == gjson.Number && b.Type == gjson.Number {
+		return a.Num > b.Num
+	}
+	return a.String() > b.String()
+}
+
+// scalars flattens the scalar values of obj into fields named by their
+// dotted path, in key order. Lists are skipped.
+func scalars(prefix string, obj gjson.Result) []Field {
+	var fields []Field
+	obj.ForEach(func(k, v gjson.Result) bool {
+		name := prefix + k.String()
+		switch {
+		case v.IsObject():
+			fields = append(fields, scalars(name+".", v)...)
+		case !v.IsArray():
+			fields = append(fields, Field{name, v.String()})
+		}
+		return true
+	})
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// counts orders m by descending count, then by value.
+func counts(m map[string]int) []Count {
+	var out []Count
+	for v, n := range m {
+		out = append(out, Count{v, n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//go:embed report.html
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04:05 MST") },
+}).Parse(reportHTML))
+
+// Write renders s as a self-contained HTML page titled title.
+func (s *Summary) Write(w io.Writer, title string) error {
+	return reportTemplate.Execute(w, struct {
+		Title string
+		*Summary
+	}{title, s})
+}
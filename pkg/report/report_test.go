@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ndfc-collector/pkg/archive"
+	"ndfc-collector/pkg/requests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCatalog = []requests.Request{
+	{URL: "/fabrics", DBKey: keyFabrics, ListPath: "fabrics", IDField: "name"},
+	{URL: "/switches", DBKey: keySwitches, ListPath: "switches", IDField: "switchId"},
+	{URL: "/fabrics/{fabricName}/vrfs", DBKey: keyVRFs, ListPath: "@this", IDField: "id"},
+	{URL: "/backups", DBKey: keyBackups, ListPath: "backups", IDField: "name"},
+	{URL: "/cluster", DBKey: keyCluster},
+}
+
+func testArchive(t *testing.T) archive.Reader {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.zip")
+	arc, err := archive.NewWriter(path)
+	require.NoError(t, err)
+	require.NoError(t, arc.Add("manage.fabrics.json", []byte(`{"fabrics":[{"name":"site1","fabricType":"VXLAN_EVPN"},{"name":"site2"}]}`)))
+	require.NoError(t, arc.Add("inventory.switches.json", []byte(`{"switches":[
+		{"switchId":"s1","fabricName":"site1","model":"N9K-C93180YC-EX","softwareVersion":"10.3(3)"},
+		{"switchId":"s2","fabricName":"site1","model":"N9K-C93180YC-EX","softwareVersion":"10.4(1)"},
+		{"switchId":"s3","fabricName":"site2","model":"N9K-C9336C-FX2","softwareVersion":"10.3(3)"}]}`)))
+	require.NoError(t, arc.Add("fabrics.site1.vrfs.json", []byte(`[{"id":1},{"id":2}]`)))
+	require.NoError(t, arc.Add("infra.backups.json", []byte(`{"backups":[
+		{"name":"weekly-1","createdTime":"2026-03-01T00:00:00Z","status":"success"},
+		{"name":"weekly-2","createdTime":"2026-03-08T00:00:00Z","status":"failed"}]}`)))
+	require.NoError(t, arc.Add("infra.cluster.config.json", []byte(`{"clusterName":"nd1","version":{"major":12,"minor":2},"nodes":[1,2]}`)))
+	arc.SetMeta("collector_version", "1.2.3")
+	arc.SetMeta(requests.MetaKey, []requests.Result{
+		{Entry: "manage.fabrics.json", URL: "/fabrics", Template: "/fabrics", DBKey: keyFabrics},
+		{Entry: "inventory.switches.json", URL: "/switches", Template: "/switches", DBKey: keySwitches},
+		{
+			Entry: "fabrics.site1.vrfs.json", URL: "/fabrics/site1/vrfs", Template: "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/site1/vrfs", Ctx: map[string]string{"fabricName": "site1"},
+		},
+		{
+			Entry: "fabrics.site2.vrfs.json", URL: "/fabrics/site2/vrfs", Template: "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/site2/vrfs", Ctx: map[string]string{"fabricName": "site2"}, Error: "HTTP 500 <Internal>",
+		},
+		{Entry: "infra.backups.json", URL: "/backups", Template: "/backups", DBKey: keyBackups},
+		{Entry: "infra.cluster.config.json", URL: "/cluster", Template: "/cluster", DBKey: keyCluster},
+	})
+	require.NoError(t, arc.Close())
+	r, err := archive.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestBuild(t *testing.T) {
+	s, err := Build(testArchive(t), testCatalog)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Field{{"collector_version", "1.2.3"}}, s.Meta)
+	assert.Equal(t, []Field{{"clusterName", "nd1"}, {"version.major", "12"}, {"version.minor", "2"}}, s.Controller)
+	assert.Equal(t, []Fabric{
+		{Name: "site1", Type: "VXLAN_EVPN", Switches: 2, VRFs: 2},
+		{Name: "site2", Type: "unknown", Switches: 1},
+	}, s.Fabrics)
+	assert.Equal(t, 3, s.Switches)
+	assert.Equal(t, []Count{{"N9K-C93180YC-EX", 2}, {"N9K-C9336C-FX2", 1}}, s.Models)
+	assert.Equal(t, []Count{{"10.3(3)", 2}, {"10.4(1)", 1}}, s.Versions)
+	assert.Equal(t, 2, s.Backups)
+	assert.Equal(t, &Backup{Name: "weekly-2", Created: "2026-03-08T00:00:00Z", Status: "failed"}, s.Latest)
+	assert.Empty(t, s.Licenses)
+	assert.Equal(t, 6, s.Requests)
+	require.Len(t, s.Failed, 1)
+	assert.Equal(t, "fabrics.site2.vrfs.json", s.Failed[0].Entry)
+}
+
+func TestWrite(t *testing.T) {
+	s, err := Build(testArchive(t), testCatalog)
+	require.NoError(t, err)
+	var sb strings.Builder
+	require.NoError(t, s.Write(&sb, "NDFC Collection Report"))
+	out := sb.String()
+
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.Contains(t, out, "<title>NDFC Collection Report</title>")
+	assert.Contains(t, out, "<td>site1</td><td>VXLAN_EVPN</td>")
+	assert.Contains(t, out, "1 of 6 requests failed")
+	assert.Contains(t, out, "HTTP 500 &lt;Internal&gt;", "values are escaped")
+	assert.Contains(t, out, "No license assignments collected.")
+	assert.NotContains(t, out, "<script", "the report is static")
+	assert.NotContains(t, out, "http://", "the report has no external resources")
+}
@@ -45,10 +45,36 @@ type Request struct {
 	URL       string                // Full host-relative API endpoint URL from the OpenAPI spec; may contain {placeholder} patterns
 	Query     map[string]string     // Query parameters
 	DependsOn map[string]Dependency // maps each URL {placeholder} name to the parent request and JSON key that supplies its value
+	Template  string                // db_key of the template a resolved request was expanded from, e.g. fabrics/{fabricName}/vrfs
+	Fabric    string                // fabric a resolved request was expanded for, if any; used in log fields
+	IfChanged ndfc.Validators       // validators of the baseline response, if any; makes the request conditional
+	// Controller versions the URL exists on, and the URLs to use instead on others
+	MinVersion   ndfc.Version  // oldest release with the URL; zero for no bound
+	MaxVersion   ndfc.Version  // newest release with the URL; zero for no bound
+	Alternatives []Alternative // URLs used instead on the releases they name
+	CatalogURL   string        // catalog URL of a request ForVersion moved to an alternative; empty otherwise
 	// Storage metadata (used by vetr for ingestion; ignored by collector HTTP logic)
-	DBKey     string `yaml:"db_key"`    // canonical key prefix (slashes→dots for filename, used as buntDB prefix)
-	ListPath  string `yaml:"list_path"` // dot-notation path to the item array in the response
-	IDField   string `yaml:"id_field"`  // JSON field used as the unique row identifier
+	DBKey    string `yaml:"db_key"`    // canonical key prefix (slashes→dots for filename, used as buntDB prefix)
+	ListPath string `yaml:"list_path"` // dot-notation path to the item array in the response
+	IDField  string `yaml:"id_field"`  // JSON field used as the unique row identifier
+}
+
+// Alternative is the URL of a request on a range of controller releases,
+// for endpoints that moved between releases.
+type Alternative struct {
+	URL        string
+	MinVersion ndfc.Version
+	MaxVersion ndfc.Version
+}
+
+// SkippedMetaKey is the archive manifest meta key of the skipped requests.
+const SkippedMetaKey = "skipped"
+
+// Skipped is a request left out of a collection.
+type Skipped struct {
+	URL    string `json:"url"`
+	DBKey  string `json:"db_key"`
+	Reason string `json:"reason"`
 }
 
 //go:embed requests.yaml
@@ -57,22 +83,53 @@ var requestsYAML []byte
 // yamlRequests is the intermediate representation used to parse requests.yaml.
 type yamlRequests struct {
 	Requests []struct {
-		URL       string                       `yaml:"url"`
-		DBKey     string                       `yaml:"db_key"`
-		ListPath  string                       `yaml:"list_path"`
-		IDField   string                       `yaml:"id_field"`
-		Query     map[string]string            `yaml:"query"`
+		URL       string            `yaml:"url"`
+		DBKey     string            `yaml:"db_key"`
+		ListPath  string            `yaml:"list_path"`
+		IDField   string            `yaml:"id_field"`
+		Query     map[string]string `yaml:"query"`
 		DependsOn map[string]struct {
 			URL string `yaml:"url"`
 			Key string `yaml:"key"`
 		} `yaml:"depends_on"`
+		yamlVersions `yaml:",inline"`
+		Alternatives []struct {
+			URL          string `yaml:"url"`
+			yamlVersions `yaml:",inline"`
+		} `yaml:"alternatives"`
 	} `yaml:"requests"`
 }
 
+// yamlVersions are the version bounds of a request or alternative.
+type yamlVersions struct {
+	MinVersion string `yaml:"min_version"`
+	MaxVersion string `yaml:"max_version"`
+}
+
+// parse returns the bounds, naming url in errors.
+func (y yamlVersions) parse(url string) (lo, hi ndfc.Version, err error) {
+	if y.MinVersion != "" {
+		if lo, err = ndfc.ParseVersion(y.MinVersion); err != nil {
+			return lo, hi, fmt.Errorf("%s: min_version: %w", url, err)
+		}
+	}
+	if y.MaxVersion != "" {
+		if hi, err = ndfc.ParseVersion(y.MaxVersion); err != nil {
+			return lo, hi, fmt.Errorf("%s: max_version: %w", url, err)
+		}
+	}
+	return lo, hi, nil
+}
+
 // GetRequests parses requests.yaml and returns normalized requests.
 func GetRequests() ([]Request, error) {
+	return parseRequests(requestsYAML)
+}
+
+// parseRequests parses a request catalog in the format of requests.yaml.
+func parseRequests(data []byte) ([]Request, error) {
 	var raw yamlRequests
-	if err := yaml.Unmarshal(requestsYAML, &raw); err != nil {
+	if err := yaml.Unmarshal(data, &raw); err != nil {
 		return nil, fmt.Errorf("parsing requests.yaml: %w", err)
 	}
 	reqs := make([]Request, 0, len(raw.Requests))
@@ -90,7 +147,96 @@ func GetRequests() ([]Request, error) {
 				req.DependsOn[placeholder] = Dependency{URL: dep.URL, Key: dep.Key}
 			}
 		}
+		var err error
+		if req.MinVersion, req.MaxVersion, err = r.yamlVersions.parse(r.URL); err != nil {
+			return nil, fmt.Errorf("parsing requests.yaml: %w", err)
+		}
+		for _, a := range r.Alternatives {
+			alt := Alternative{URL: a.URL}
+			if alt.MinVersion, alt.MaxVersion, err = a.yamlVersions.parse(a.URL); err != nil {
+				return nil, fmt.Errorf("parsing requests.yaml: %w", err)
+			}
+			req.Alternatives = append(req.Alternatives, alt)
+		}
 		reqs = append(reqs, req)
 	}
 	return reqs, nil
 }
+
+// ForVersion returns the requests to send to a controller running release
+// v. The first alternative whose range contains v replaces the URL of its
+// request, and dependencies on the request are pointed at it. Otherwise a
+// request outside its own range is skipped, together with the requests
+// depending on it. With an unknown release the requests are returned as
+// listed.
+func ForVersion(reqs []Request, v ndfc.Version) ([]Request, []Skipped) {
+	if v.IsZero() {
+		return reqs, nil
+	}
+	urls := make(map[string]string, len(reqs)) // template URL -> URL for v
+	reasons := map[string]string{}             // template URL -> why it is skipped
+	for _, r := range reqs {
+		urls[r.URL] = r.URL
+		for _, alt := range r.Alternatives {
+			if v.Between(alt.MinVersion, alt.MaxVersion) {
+				urls[r.URL] = alt.URL
+				break
+			}
+		}
+		if urls[r.URL] == r.URL && !v.Between(r.MinVersion, r.MaxVersion) {
+			reasons[r.URL] = fmt.Sprintf("not available on %s (%s)", v, versionRange(r.MinVersion, r.MaxVersion))
+		}
+	}
+	// Skip the dependents of skipped requests until none are left.
+	for changed := true; changed; {
+		changed = false
+		for _, r := range reqs {
+			if _, ok := reasons[r.URL]; ok {
+				continue
+			}
+			for _, dep := range r.DependsOn {
+				if _, ok := reasons[dep.URL]; ok {
+					reasons[r.URL] = "depends on skipped " + dep.URL
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var selected []Request
+	var skipped []Skipped
+	for _, r := range reqs {
+		if reason, ok := reasons[r.URL]; ok {
+			skipped = append(skipped, Skipped{URL: r.URL, DBKey: r.DBKey, Reason: reason})
+			continue
+		}
+		if u := urls[r.URL]; u != r.URL {
+			r.CatalogURL, r.URL = r.URL, u
+		}
+		if len(r.DependsOn) > 0 {
+			deps := make(map[string]Dependency, len(r.DependsOn))
+			for placeholder, dep := range r.DependsOn {
+				if u, ok := urls[dep.URL]; ok {
+					dep.URL = u
+				}
+				deps[placeholder] = dep
+			}
+			r.DependsOn = deps
+		}
+		selected = append(selected, r)
+	}
+	return selected, skipped
+}
+
+// versionRange describes the inclusive bounds lo and hi.
+func versionRange(lo, hi ndfc.Version) string {
+	switch {
+	case lo.IsZero():
+		return "requires " + hi.String() + " or older"
+	case hi.IsZero():
+		return "requires " + lo.String() + " or newer"
+	default:
+		return "requires " + lo.String() + " to " + hi.String()
+	}
+}
@@ -19,7 +19,10 @@ package requests
 import (
 	"testing"
 
+	"ndfc-collector/pkg/ndfc"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetRequests(t *testing.T) {
@@ -71,3 +74,105 @@ func TestDependentRequests(t *testing.T) {
 		}
 	}
 }
+
+func TestForVersion(t *testing.T) {
+	version := func(s string) ndfc.Version {
+		v, err := ndfc.ParseVersion(s)
+		require.NoError(t, err)
+		return v
+	}
+	reqs := []Request{
+		{
+			URL:   "/api/v1/manage/fabrics",
+			DBKey: "manage/fabrics",
+			Alternatives: []Alternative{
+				{URL: "/appcenter/fabrics", MaxVersion: version("12.1")},
+			},
+		},
+		{
+			URL:   "/fabrics/{fabricName}/vrfs",
+			DBKey: "fabrics/{fabricName}/vrfs",
+			DependsOn: map[string]Dependency{
+				"fabricName": {URL: "/api/v1/manage/fabrics", Key: "name"},
+			},
+		},
+		{URL: "/api/v1/new", DBKey: "new", MinVersion: version("12.2")},
+		{
+			URL:   "/api/v1/new/{id}",
+			DBKey: "new/{id}",
+			DependsOn: map[string]Dependency{
+				"id": {URL: "/api/v1/new", Key: "id"},
+			},
+		},
+	}
+
+	selected, skipped := ForVersion(reqs, version("12.1.3b"))
+	require.Len(t, selected, 2)
+	assert.Equal(t, "/appcenter/fabrics", selected[0].URL)
+	assert.Equal(t, "/api/v1/manage/fabrics", selected[0].CatalogURL, "the catalog URL is kept")
+	assert.Equal(t, "/appcenter/fabrics", selected[1].DependsOn["fabricName"].URL,
+		"dependencies follow the alternative URL")
+	assert.Equal(t, []Skipped{
+		{URL: "/api/v1/new", DBKey: "new", Reason: "not available on 12.1.3b (requires 12.2 or newer)"},
+		{URL: "/api/v1/new/{id}", DBKey: "new/{id}", Reason: "depends on skipped /api/v1/new"},
+	}, skipped)
+	assert.Equal(t, "/api/v1/manage/fabrics", reqs[1].DependsOn["fabricName"].URL, "input is not modified")
+
+	selected, skipped = ForVersion(reqs, version("12.2.2"))
+	assert.Len(t, selected, 4)
+	assert.Empty(t, skipped)
+	assert.Equal(t, "/api/v1/manage/fabrics", selected[0].URL)
+	assert.Empty(t, selected[0].CatalogURL)
+
+	selected, skipped = ForVersion(reqs, ndfc.Version{})
+	assert.Equal(t, reqs, selected, "every request is kept when the version is unknown")
+	assert.Empty(t, skipped)
+}
+
+func TestParseRequests_Versions(t *testing.T) {
+	reqs, err := parseRequests([]byte(`
+requests:
+  - url: /api/v1/manage/fabrics
+    db_key: manage/fabrics
+    min_version: "12.2"
+    alternatives:
+      - url: /appcenter/fabrics
+        max_version: 12.1.3b
+`))
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "12.2", reqs[0].MinVersion.String())
+	assert.True(t, reqs[0].MaxVersion.IsZero())
+	require.Len(t, reqs[0].Alternatives, 1)
+	assert.Equal(t, "/appcenter/fabrics", reqs[0].Alternatives[0].URL)
+	assert.Equal(t, "12.1.3b", reqs[0].Alternatives[0].MaxVersion.String())
+
+	_, err = parseRequests([]byte(`
+requests:
+  - url: /api/v1/manage/fabrics
+    max_version: latest
+`))
+	assert.ErrorContains(t, err, "/api/v1/manage/fabrics: max_version")
+}
+
+// TestGetRequests_Versions checks the release bounds in requests.yaml: 12.1
+// gets the /appcenter endpoints, including the fabrics the per-fabric
+// requests depend on, and 12.2 gets every request.
+func TestGetRequests_Versions(t *testing.T) {
+	reqs, err := GetRequests()
+	require.NoError(t, err)
+	// The catalog declares no version bounds, so every request is sent to
+	// every release.
+	for _, r := range reqs {
+		assert.Empty(t, r.MinVersion, r.URL)
+		assert.Empty(t, r.MaxVersion, r.URL)
+		assert.Empty(t, r.Alternatives, r.URL)
+	}
+	for _, s := range []string{"12.1.3b", "12.2.2"} {
+		v, err := ndfc.ParseVersion(s)
+		require.NoError(t, err)
+		selected, skipped := ForVersion(reqs, v)
+		assert.Equal(t, reqs, selected, s)
+		assert.Empty(t, skipped, s)
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requests
+
+import "ndfc-collector/pkg/ndfc"
+
+// MetaKey is the archive manifest metadata key holding the Results of a
+// collection.
+const MetaKey = "requests"
+
+// Result records how a single resolved request was collected. Results are
+// stored in the archive manifest so that tools reading the archive can
+// relate each entry back to its catalog request.
+type Result struct {
+	Entry    string            `json:"entry"`             // archive entry name
+	URL      string            `json:"url"`               // resolved URL
+	Template string            `json:"template"`          // catalog URL template
+	DBKey    string            `json:"db_key,omitempty"`  // resolved db_key
+	Ctx      map[string]string `json:"ctx,omitempty"`     // placeholder values from parent responses
+	Error    string            `json:"error,omitempty"`   // set when the request failed
+	Carried  bool              `json:"carried,omitempty"` // copied unchanged from the baseline archive
+
+	// Validators are the cache validators of the response, used to make
+	// the request conditional when the archive is a baseline.
+	ndfc.Validators
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Retain returns the times to keep from a set of collection times: the
+// newest of each of the daily most recent days with a collection and the
+// newest of each of the weekly most recent ISO weeks with one. Days and
+// weeks are taken in the times' location. The result is newest first.
+func Retain(times []time.Time, daily, weekly int) []time.Time {
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].After(sorted[j]) })
+
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	var kept []time.Time
+	for _, t := range sorted {
+		keep := false
+		if day := t.Format("2006-01-02"); !days[day] && len(days) < daily {
+			days[day] = true
+			keep = true
+		}
+		year, w := t.ISOWeek()
+		if week := fmt.Sprintf("%d-W%02d", year, w); !weeks[week] && len(weeks) < weekly {
+			weeks[week] = true
+			keep = true
+		}
+		if keep {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	for _, tc := range []struct {
+		expr, from, want string
+	}{
+		{"0 2 * * *", "2026-10-19 01:30", "2026-10-19 02:00"},
+		{"0 2 * * *", "2026-10-19 02:00", "2026-10-20 02:00"},
+		{"@daily", "2026-10-19 13:00", "2026-10-20 00:00"},
+		{"@hourly", "2026-10-19 13:59", "2026-10-19 14:00"},
+		{"*/15 * * * *", "2026-10-19 13:16", "2026-10-19 13:30"},
+		{"30 1 * * mon-fri", "2026-10-17 12:00", "2026-10-19 01:30"}, // Saturday to Monday
+		{"0 0 * * 7", "2026-10-19 00:00", "2026-10-25 00:00"},        // 7 is Sunday
+		{"0 3 1 jan,jul *", "2026-10-19 00:00", "2027-01-01 03:00"},
+		{"0 0 29 2 *", "2026-10-19 00:00", "2028-02-29 00:00"},
+		{"0 0 13 * fri", "2026-10-19 00:00", "2026-10-23 00:00"}, // either day field
+		{"5/20 9 * * *", "2026-10-19 09:30", "2026-10-19 09:45"},
+	} {
+		c, err := Parse(tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, date(tc.want), c.Next(date(tc.from)), tc.expr)
+	}
+
+	c, err := Parse("0 0 31 2 *")
+	require.NoError(t, err)
+	assert.True(t, c.Next(date("2026-10-19 00:00")).IsZero())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * foo *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@often",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestRetain(t *testing.T) {
+	var times []time.Time
+	// Nightly collections for three weeks, with two on Oct 18.
+	for d := 0; d < 21; d++ {
+		times = append(times, date("2026-10-01 02:00").AddDate(0, 0, d))
+	}
+	times = append(times, date("2026-10-18 14:00"))
+
+	kept := Retain(times, 3, 2)
+	assert.Equal(t, []time.Time{
+		date("2026-10-21 02:00"), // Wednesday, newest of this week
+		date("2026-10-20 02:00"),
+		date("2026-10-19 02:00"),
+		date("2026-10-18 14:00"), // Sunday, newest of last week
+	}, kept)
+
+	assert.Len(t, Retain(times, 30, 0), 21, "one per day")
+	assert.Empty(t, Retain(times, 0, 0))
+	assert.Len(t, Retain(times, 0, 10), 4, "Oct 1 to 21 spans four ISO weeks")
+}
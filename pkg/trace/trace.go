@@ -0,0 +1,216 @@
+// Package trace records OpenTelemetry traces of a collection run: a root
+// span for the run, a span per dependency level and a span per request.
+
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MetaKey is the manifest metadata key holding the run's trace ID.
+const MetaKey = "trace_id"
+
+// Span kinds, as numbered by OTLP.
+const (
+	kindInternal = 1
+	kindClient   = 3
+)
+
+// Span status codes, as numbered by OTLP.
+const (
+	statusOK    = 1
+	statusError = 2
+)
+
+// Span is a timed operation within the run.
+type Span struct {
+	mu       sync.Mutex
+	tracer   *Tracer
+	id       string
+	parent   string
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    []attr
+	status   int
+	message  string
+	finished bool
+}
+
+type attr struct {
+	key   string
+	value any
+}
+
+// Tracer collects the spans of one run under a single trace ID. Level
+// spans are children of the root span, and request spans are children of
+// the level being fetched. A nil Tracer, and the nil Spans it returns,
+// ignore every call, for runs without tracing.
+type Tracer struct {
+	mu      sync.Mutex
+	service string
+	version string
+	traceID string
+	now     func() time.Time
+	root    *Span
+	level   *Span
+	spans   []*Span
+}
+
+// New starts a trace of a run, opening its root span with the given name.
+func New(service, version, name string) *Tracer {
+	return newTracer(service, version, name, time.Now)
+}
+
+func newTracer(service, version, name string, now func() time.Time) *Tracer {
+	t := &Tracer{
+		service: service,
+		version: version,
+		traceID: randomID(16),
+		now:     now,
+	}
+	t.root = t.start("", name, kindInternal)
+	return t
+}
+
+// randomID returns n random bytes, hex encoded.
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (t *Tracer) start(parent, name string, kind int) *Span {
+	s := &Span{
+		tracer: t,
+		id:     randomID(8),
+		parent: parent,
+		name:   name,
+		kind:   kind,
+		start:  t.now(),
+	}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+// TraceID returns the hex trace ID of the run, or "" if t is nil.
+func (t *Tracer) TraceID() string {
+	if t == nil {
+		return ""
+	}
+	return t.traceID
+}
+
+// Root returns the run's root span.
+func (t *Tracer) Root() *Span {
+	if t == nil {
+		return nil
+	}
+	return t.root
+}
+
+// StartLevel ends the span of the previous dependency level, if any, and
+// opens one for level n with total requests.
+func (t *Tracer) StartLevel(n, total int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	prev := t.level
+	t.level = t.start(t.root.id, fmt.Sprintf("level %d", n), kindInternal)
+	t.mu.Unlock()
+	prev.End()
+	t.level.Set("level", n)
+	t.level.Set("requests", total)
+}
+
+// StartRequest opens a span for a request of the current level.
+func (t *Tracer) StartRequest(name string) *Span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent := t.root
+	if t.level != nil {
+		parent = t.level
+	}
+	return t.start(parent.id, name, kindClient)
+}
+
+// Finish ends the current level and the root span. err, if not nil,
+// marks the run as failed.
+func (t *Tracer) Finish(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	level := t.level
+	t.level = nil
+	t.mu.Unlock()
+	level.End()
+	t.root.SetError(err)
+	t.root.End()
+}
+
+// Set records an attribute. Values are strings, bools, integers or floats;
+// anything else is recorded as its string form.
+func (s *Span) Set(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attr{key, value})
+}
+
+// SetError marks the span as failed with err, or as succeeded if err is
+// nil.
+func (s *Span) SetError(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.status = statusError
+		s.message = err.Error()
+	} else {
+		s.status = statusOK
+		s.message = ""
+	}
+}
+
+// End ends the span. Later calls are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.finished {
+		s.end = s.tracer.now()
+		s.finished = true
+	}
+}
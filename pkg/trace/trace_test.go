@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Copyright 2026 Cisco Systems, Inc. and their affiliates
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock returns a time source advancing a second per call.
+func clock() func() time.Time {
+	now := time.Unix(1700000000, 0)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func testTracer() *Tracer {
+	tr := newTracer("ndfc-collector", "1.0.0", "collect", clock())
+	tr.Root().Set("ndfc.host", "10.0.0.1")
+	tr.StartLevel(0, 1)
+	s := tr.StartRequest("manage.fabrics.json")
+	s.Set("db_key", "manage/fabrics")
+	s.Set("http.response.status_code", 200)
+	s.Set("http.response.body.size", int64(2048))
+	s.Set("retries", 0)
+	s.SetError(nil)
+	s.End()
+	tr.StartLevel(1, 1)
+	s = tr.StartRequest("fabrics.f1.vrfs.json")
+	s.Set("http.response.status_code", 500)
+	s.SetError(errors.New("received HTTP status 500"))
+	s.End()
+	tr.Finish(errors.New("some data could not be fetched"))
+	return tr
+}
+
+func TestTracer(t *testing.T) {
+	tr := testTracer()
+	req := tr.request()
+	require.Len(t, req.ResourceSpans, 1)
+	assert.Equal(t, "service.name", req.ResourceSpans[0].Resource.Attributes[0].Key)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 5)
+
+	byName := map[string]otlpSpan{}
+	for _, s := range spans {
+		assert.Equal(t, tr.TraceID(), s.TraceID)
+		assert.Len(t, s.SpanID, 16)
+		byName[s.Name] = s
+	}
+	root := byName["collect"]
+	assert.Empty(t, root.ParentSpanID)
+	assert.Len(t, root.TraceID, 32)
+	assert.Equal(t, statusError, root.Status.Code)
+	assert.Equal(t, root.SpanID, byName["level 0"].ParentSpanID)
+	assert.Equal(t, root.SpanID, byName["level 1"].ParentSpanID)
+	assert.Equal(t, byName["level 0"].SpanID, byName["manage.fabrics.json"].ParentSpanID)
+	assert.Equal(t, byName["level 1"].SpanID, byName["fabrics.f1.vrfs.json"].ParentSpanID)
+
+	fabrics := byName["manage.fabrics.json"]
+	assert.Equal(t, kindClient, fabrics.Kind)
+	assert.Equal(t, statusOK, fabrics.Status.Code)
+	assert.Less(t, fabrics.StartTimeUnixNano, fabrics.EndTimeUnixNano)
+	assert.Equal(t, "2048", *fabrics.Attributes[2].Value.IntValue)
+	assert.Equal(t, "received HTTP status 500", byName["fabrics.f1.vrfs.json"].Status.Message)
+	assert.Equal(t, "1", *byName["level 1"].Attributes[0].Value.IntValue)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testTracer().WriteJSON(&buf))
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Contains(t, buf.String(), `"stringValue": "ndfc-collector"`)
+	assert.Contains(t, buf.String(), `"intValue": "200"`)
+	assert.Contains(t, buf.String(), `"name": "fabrics.f1.vrfs.json"`)
+}
+
+func TestExport(t *testing.T) {
+	var path string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	tr := testTracer()
+	require.NoError(t, tr.Export(srv.URL+"/"))
+	assert.Equal(t, "/v1/traces", path)
+	assert.Contains(t, string(body), tr.TraceID())
+
+	require.NoError(t, tr.Export(srv.URL+"/v1/traces"))
+	assert.Equal(t, "/v1/traces", path, "a full traces URL is used as is")
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	assert.ErrorContains(t, tr.Export(failing.URL), "400")
+}
+
+func TestNilTracer(t *testing.T) {
+	var tr *Tracer
+	tr.StartLevel(0, 1)
+	s := tr.StartRequest("x")
+	s.Set("k", 1)
+	s.SetError(nil)
+	s.End()
+	tr.Finish(nil)
+	assert.Empty(t, tr.TraceID())
+	assert.NoError(t, tr.WriteJSON(io.Discard))
+	assert.NoError(t, tr.Export("http://127.0.0.1:1"))
+}